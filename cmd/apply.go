@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"github.com/cloudentity/cac/internal/cac"
+	"github.com/cloudentity/cac/internal/cac/plan"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slog"
+)
+
+var (
+	applyCmd = &cobra.Command{
+		Use:   "apply",
+		Short: "Apply a plan created with the plan command",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				app *cac.Application
+				p   *plan.Plan
+				err error
+			)
+
+			if err = requireSingleWorkspace(); err != nil {
+				return err
+			}
+
+			if p, err = plan.Read(applyConfig.Plan); err != nil {
+				return err
+			}
+
+			if p.Tenant != rootConfig.Tenant || p.Workspace != rootConfig.Workspace {
+				return errors.Errorf("plan was created for workspace %q (tenant: %v)", p.Workspace, p.Tenant)
+			}
+
+			if app, err = cac.InitApp(rootConfig.ConfigPath, rootConfig.Profile, rootConfig.Tenant, rootConfig.Values...); err != nil {
+				return err
+			}
+
+			slog.
+				With("workspace", p.Workspace).
+				With("tenant", p.Tenant).
+				With("plan", applyConfig.Plan).
+				With("fingerprint", p.Fingerprint).
+				Info("Applying plan")
+
+			if err = p.Apply(cmd.Context(), app.Client, applyConfig.Mode); err != nil {
+				return errors.Wrap(err, "failed to apply plan")
+			}
+
+			slog.Info("applied plan")
+
+			return nil
+		},
+	}
+	applyConfig struct {
+		Plan string
+		Mode string
+	}
+)
+
+func init() {
+	applyCmd.PersistentFlags().StringVar(&applyConfig.Plan, "plan", "cac.plan", "Path to the plan file")
+	applyCmd.PersistentFlags().StringVar(&applyConfig.Mode, "mode", "update", "One of ignore, fail, update")
+}
@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
 	"github.com/cloudentity/cac/internal/cac"
 	"github.com/cloudentity/cac/internal/cac/api"
 	"github.com/cloudentity/cac/internal/cac/diff"
+	"github.com/cloudentity/cac/internal/cac/storage"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"golang.org/x/exp/slog"
@@ -14,15 +16,40 @@ var (
 	diffCmd = &cobra.Command{
 		Use:   "diff",
 		Short: "Compare configuration",
-		RunE: func(cmd *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			var (
-				app    *cac.Application
-				result string
-				source api.Source
-				target api.Source
-				err    error
+				app     *cac.Application
+				result  string
+				source  api.Source
+				target  api.Source
+				server1 models.Rfc7396PatchOperation
+				server2 models.Rfc7396PatchOperation
+				changes []diff.Change
+				opts    []diff.Option
+
+				constructor = storage.InitServerStorage
 			)
 
+			if diffConfig.ExitCode {
+				// like git diff --exit-code: 1 means differences, so errors are reported with 2
+				defer func() {
+					if err != nil {
+						err = &ExitError{Code: 2, Err: err}
+						return
+					}
+
+					if len(changes) > 0 {
+						cmd.SilenceErrors = true
+						cmd.SilenceUsage = true
+						err = &ExitError{Code: 1, Err: errors.New("differences found")}
+					}
+				}()
+			}
+
+			if err = requireSingleWorkspace(); err != nil {
+				return err
+			}
+
 			slog.
 				With("workspace", rootConfig.Workspace).
 				With("config", rootConfig.ConfigPath).
@@ -33,9 +60,11 @@ var (
 				With("volatile", diffConfig.FilterVolatile).
 				With("filters", diffConfig.Filters).
 				With("out", diffConfig.Out).
+				With("output", diffConfig.Output).
+				With("format", diffConfig.Format).
 				Info("Comparing workspace configuration")
 
-			if app, err = cac.InitApp(rootConfig.ConfigPath, rootConfig.Profile, rootConfig.Tenant); err != nil {
+			if app, err = cac.InitApp(rootConfig.ConfigPath, rootConfig.Profile, rootConfig.Tenant, rootConfig.Values...); err != nil {
 				return err
 			}
 
@@ -49,13 +78,33 @@ var (
 
 			slog.Info("Comparing configurations", "source", source, "target", target)
 
-			if result, err = diff.Diff(cmd.Context(), source, target, rootConfig.Workspace,
+			if rootConfig.Tenant {
+				constructor = storage.InitTenantStorage
+			}
+
+			opts = []diff.Option{
 				diff.Colorize(diffConfig.Colors),
 				diff.OnlyPresent(diffConfig.OnlyPresent),
 				diff.Filters(diffConfig.Filters...),
 				diff.WithSecrets(diffConfig.WithSecrets),
+				diff.FilterSecretFields(!diffConfig.WithSecrets),
 				diff.FilterVolatileFields(diffConfig.FilterVolatile),
-			); err != nil {
+				diff.Output(diff.OutputFormat(diffConfig.Output)),
+				diff.Format(diff.TextFormat(diffConfig.Format)),
+				diff.StorageLayout(constructor, rootConfig.Workspace),
+			}
+
+			if server1, server2, err = diff.Read(cmd.Context(), source, target, rootConfig.Workspace, opts...); err != nil {
+				return err
+			}
+
+			if changes, err = diff.Changes(server1, server2, opts...); err != nil {
+				return err
+			}
+
+			if diffConfig.Quiet {
+				result = diff.FormatCounts(diff.CountByCollection(changes)) + "\n"
+			} else if result, err = diff.Render(server1, server2, opts...); err != nil {
 				return err
 			}
 
@@ -83,6 +132,10 @@ var (
 		Filters        []string
 		Out            string
 		FilterVolatile bool
+		Output         string
+		Format         string
+		ExitCode       bool
+		Quiet          bool
 	}
 )
 
@@ -95,6 +148,11 @@ func init() {
 	diffCmd.PersistentFlags().StringVar(&diffConfig.Out, "out", "-", "Diff output. It can be a file or '-' for stdout")
 	diffCmd.PersistentFlags().BoolVar(&diffConfig.WithSecrets, "with-secrets", false, "Compare secrets")
 	diffCmd.PersistentFlags().BoolVar(&diffConfig.FilterVolatile, "no-volatile", false, "Ignore volatile fields")
+	diffCmd.PersistentFlags().StringVar(&diffConfig.Output, "output", "text", "Output format. One of text, json (list of changes), yaml (list of changes), jsonpatch (RFC 6902), merge-patch (RFC 7396)")
+
+	diffCmd.PersistentFlags().StringVar(&diffConfig.Format, "format", "tree", "Text output format. One of tree (full comparison), summary (changed entities per collection), files (unified diff of files in storage layout)")
+	diffCmd.PersistentFlags().BoolVar(&diffConfig.ExitCode, "exit-code", false, "Exit with 1 if there are differences, 0 if there are none and 2 on errors")
+	diffCmd.PersistentFlags().BoolVar(&diffConfig.Quiet, "quiet", false, "Print only a summary of differing resources per collection")
 
 	mustMarkRequired(diffCmd, "source", "target")
 }
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ExitError carries the process exit code for commands which use exit codes to report results
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit code %d", e.Code)
+	}
+
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the process exit code for the error returned by Execute
+func ExitCode(err error) int {
+	var exitErr *ExitError
+
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+
+	return 1
+}
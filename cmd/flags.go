@@ -1,6 +1,9 @@
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
 
 func mustMarkRequired(cmd *cobra.Command, flags ...string) {
 	for _, flag := range flags {
@@ -11,3 +14,15 @@ func mustMarkRequired(cmd *cobra.Command, flags ...string) {
 		}
 	}
 }
+
+// requireWorkspaceFlags fails unless the workspace, tenant or all-workspaces flag is set
+// a cobra flag group cannot be used, as its annotations are stored in the persistent flags shared by all commands
+func requireWorkspaceFlags(cmd *cobra.Command, _ []string) error {
+	for _, flag := range []string{"workspace", "tenant", "all-workspaces"} {
+		if cmd.Flags().Changed(flag) {
+			return nil
+		}
+	}
+
+	return errors.New("at least one of the flags in the group [workspace tenant all-workspaces] is required")
+}
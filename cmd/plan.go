@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac"
+	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/cloudentity/cac/internal/cac/diff"
+	"github.com/cloudentity/cac/internal/cac/plan"
+	"github.com/cloudentity/cac/internal/cac/provenance"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slog"
+	"os"
+)
+
+var (
+	planCmd = &cobra.Command{
+		Use:   "plan",
+		Short: "Compute changes required to make remote configuration match local configuration",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				app       *cac.Application
+				data      models.Rfc7396PatchOperation
+				p         *plan.Plan
+				positions = provenance.Map{}
+				result    string
+				err       error
+			)
+
+			if err = requireSingleWorkspace(); err != nil {
+				return err
+			}
+
+			if app, err = cac.InitApp(rootConfig.ConfigPath, rootConfig.Profile, rootConfig.Tenant, rootConfig.Values...); err != nil {
+				return err
+			}
+
+			slog.
+				With("workspace", rootConfig.Workspace).
+				With("tenant", rootConfig.Tenant).
+				With("filters", planConfig.Filters).
+				With("out", planConfig.Out).
+				Info("Planning configuration changes")
+
+			if data, err = app.Storage.Read(
+				cmd.Context(),
+				api.WithWorkspace(rootConfig.Workspace),
+				api.WithFilters(planConfig.Filters),
+				api.WithProvenance(positions),
+			); err != nil {
+				return err
+			}
+
+			if !planConfig.NoLocalValidate {
+				if err = app.Validator.Validate(&data); err != nil {
+					return errors.Wrap(positions.Locate(err), "failed to validate configuration")
+				}
+			}
+
+			if p, err = plan.Create(
+				cmd.Context(),
+				data,
+				app.Client,
+				api.WithWorkspace(rootConfig.Workspace),
+				api.WithFilters(planConfig.Filters),
+			); err != nil {
+				return err
+			}
+
+			p.Tenant = rootConfig.Tenant
+
+			if err = plan.Write(planConfig.Out, p); err != nil {
+				return err
+			}
+
+			if p.Empty() {
+				slog.Info("no changes, remote configuration is up to date", "plan", planConfig.Out)
+				return nil
+			}
+
+			if result, err = diff.Tree(p.Patch, models.Rfc7396PatchOperation{}, diff.Colorize(planConfig.Colors)); err != nil {
+				return err
+			}
+
+			if _, err = os.Stdout.Write([]byte(result)); err != nil {
+				return errors.Wrap(err, "failed to write plan to stdout")
+			}
+
+			slog.Info("plan saved", "plan", planConfig.Out, "fingerprint", p.Fingerprint)
+
+			return nil
+		},
+	}
+	planConfig struct {
+		Out             string
+		Filters         []string
+		Colors          bool
+		NoLocalValidate bool
+	}
+)
+
+func init() {
+	planCmd.PersistentFlags().StringVar(&planConfig.Out, "out", "cac.plan", "Path to the plan file")
+	planCmd.PersistentFlags().StringSliceVar(&planConfig.Filters, "filter", []string{}, "Plan only selected resources")
+	planCmd.PersistentFlags().BoolVar(&planConfig.Colors, "colors", true, "Colorize output")
+	planCmd.PersistentFlags().BoolVar(&planConfig.NoLocalValidate, "no-validate", false, "Skip local validation")
+}
@@ -1,9 +1,16 @@
 package cmd
 
 import (
+	"context"
+	"path"
+
 	"github.com/cloudentity/acp-client-go/clients/hub/models"
 	"github.com/cloudentity/cac/internal/cac"
 	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/cloudentity/cac/internal/cac/diff"
+	"github.com/cloudentity/cac/internal/cac/secrets"
+	"github.com/cloudentity/cac/internal/cac/storage"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"golang.org/x/exp/slog"
 )
@@ -14,45 +21,160 @@ var (
 		Short: "Pull existing configuration",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var (
-				app  *cac.Application
-				data models.Rfc7396PatchOperation
-				err  error
+				app *cac.Application
+				err error
 			)
 
-			if app, err = cac.InitApp(rootConfig.ConfigPath, rootConfig.Profile, rootConfig.Tenant); err != nil {
-				return err
+			if pullConfig.SecretsTo != "" && !pullConfig.WithSecrets {
+				return errors.New("--secrets-to requires --with-secrets")
 			}
 
-			slog.
-				With("workspace", rootConfig.Workspace).
-				With("tenant", rootConfig.Tenant).
-				With("filters", pullConfig.Filters).
-				With("config", rootConfig.ConfigPath).
-				Info("Pulling configuration")
-
-			if data, err = app.Client.Read(
-				cmd.Context(),
-				api.WithWorkspace(rootConfig.Workspace),
-				api.WithSecrets(pullConfig.WithSecrets),
-				api.WithFilters(pullConfig.Filters),
-			); err != nil {
-				return err
+			if pullConfig.Encrypt && !pullConfig.WithSecrets {
+				return errors.New("--encrypt requires --with-secrets")
 			}
 
-			if err = app.Storage.Write(cmd.Context(), data, api.WithWorkspace(rootConfig.Workspace)); err != nil {
+			if app, err = cac.InitApp(rootConfig.ConfigPath, rootConfig.Profile, rootConfig.Tenant, rootConfig.Values...); err != nil {
 				return err
 			}
 
-			return nil
+			// fail before pulling when the provider is not registered
+			if pullConfig.SecretsTo != "" {
+				if _, err = secrets.Lookup(pullConfig.SecretsTo); err != nil {
+					return err
+				}
+			}
+
+			if rootConfig.MultiWorkspace() {
+				return forEachWorkspace(cmd.Context(), app, func(ctx context.Context, workspace string) error {
+					return pull(ctx, app, workspace)
+				})
+			}
+
+			return pull(cmd.Context(), app, rootConfig.Workspace)
 		},
 	}
 	pullConfig struct {
 		WithSecrets bool
+		SecretsTo   string
+		Encrypt     bool
 		Filters     []string
 	}
 )
 
+func pull(ctx context.Context, app *cac.Application, workspace string) error {
+	var (
+		data models.Rfc7396PatchOperation
+		err  error
+	)
+
+	slog.
+		With("workspace", workspace).
+		With("tenant", rootConfig.Tenant).
+		With("filters", pullConfig.Filters).
+		With("config", rootConfig.ConfigPath).
+		Info("Pulling configuration")
+
+	if data, err = app.Client.Read(
+		ctx,
+		api.WithWorkspace(workspace),
+		api.WithSecrets(pullConfig.WithSecrets),
+		api.WithFilters(pullConfig.Filters),
+	); err != nil {
+		return err
+	}
+
+	if pullConfig.SecretsTo != "" {
+		if err = storeSecrets(data, workspace); err != nil {
+			return err
+		}
+	}
+
+	if pullConfig.Encrypt {
+		if err = encryptSecrets(ctx, app, workspace, data); err != nil {
+			return err
+		}
+	}
+
+	if err = app.Storage.Write(ctx, data, api.WithWorkspace(workspace)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// storeSecrets writes values of secret fields to the provider selected with --secrets-to
+// and replaces them with template expressions resolving the stored secrets
+func storeSecrets(data models.Rfc7396PatchOperation, workspace string) error {
+	var prefix = workspace
+
+	if rootConfig.Tenant {
+		prefix = "tenant"
+	}
+
+	return secrets.Replace(data, diff.IsSecret, func(pointer string, value string) (any, error) {
+		var (
+			ref string
+			err error
+		)
+
+		if ref, err = secrets.Store(pullConfig.SecretsTo, path.Join(prefix, pointer), value); err != nil {
+			return nil, errors.Wrapf(err, "failed to store secret %s", pointer)
+		}
+
+		slog.Debug("stored secret", "pointer", pointer, "reference", ref)
+
+		return storage.InlineTemplate(secrets.Expression(ref)), nil
+	})
+}
+
+// encryptSecrets encrypts values of secret fields, so they can be committed, values are decrypted when read from storage
+// age encryption is not deterministic, so secrets which did not change keep their current ciphertext and files are not rewritten
+func encryptSecrets(ctx context.Context, app *cac.Application, workspace string, data models.Rfc7396PatchOperation) error {
+	var (
+		age     = ageConfig(app)
+		local   models.Rfc7396PatchOperation
+		current = map[string]string{}
+		err     error
+	)
+
+	if local, err = app.Storage.Read(
+		ctx,
+		api.WithWorkspace(workspace),
+		api.WithFilters(pullConfig.Filters),
+		api.WithEncrypted(true),
+	); err != nil {
+		return errors.Wrap(err, "failed to read local configuration")
+	}
+
+	if err = secrets.Replace(local, diff.IsSecret, func(pointer string, value string) (any, error) {
+		current[pointer] = value
+		return value, nil
+	}); err != nil {
+		return err
+	}
+
+	return secrets.Replace(data, diff.IsSecret, func(pointer string, value string) (any, error) {
+		if existing, ok := current[pointer]; ok && secrets.IsEncrypted(existing) {
+			if plain, err := age.Decrypt(existing); err == nil && plain == value {
+				return existing, nil
+			}
+		}
+
+		encrypted, err := age.Encrypt(value)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to encrypt secret %s", pointer)
+		}
+
+		return encrypted, nil
+	})
+}
+
 func init() {
 	pullCmd.PersistentFlags().BoolVar(&pullConfig.WithSecrets, "with-secrets", false, "Pull secrets")
+	pullCmd.PersistentFlags().StringVar(&pullConfig.SecretsTo, "secrets-to", "", "Store pulled secrets with the secret provider, i.e. file, and keep references to them in configuration files. Requires --with-secrets")
+	pullCmd.PersistentFlags().BoolVar(&pullConfig.Encrypt, "encrypt", false, "Encrypt pulled secrets with age recipients configured in secrets.age.recipients. Requires --with-secrets")
+
+	pullCmd.MarkFlagsMutuallyExclusive("secrets-to", "encrypt")
 	pullCmd.PersistentFlags().StringSliceVar(&pullConfig.Filters, "filter", []string{}, "Pull only selected resources")
 }
@@ -1,10 +1,20 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
 	"github.com/cloudentity/acp-client-go/clients/hub/models"
 	"github.com/cloudentity/cac/internal/cac"
 	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/cloudentity/cac/internal/cac/diff"
+	"github.com/cloudentity/cac/internal/cac/provenance"
+	"github.com/cloudentity/cac/internal/cac/snapshot"
 	"github.com/cloudentity/cac/internal/cac/storage"
+	"github.com/cloudentity/cac/internal/cac/utils"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"golang.org/x/exp/slog"
@@ -16,76 +26,336 @@ var (
 		Short: "push local configuration",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var (
-				app  *cac.Application
-				data models.Rfc7396PatchOperation
-				err  error
+				app *cac.Application
+				err error
 			)
 
-			if app, err = cac.InitApp(rootConfig.ConfigPath, rootConfig.Profile, rootConfig.Tenant); err != nil {
+			if app, err = cac.InitApp(rootConfig.ConfigPath, rootConfig.Profile, rootConfig.Tenant, rootConfig.Values...); err != nil {
 				return err
 			}
 
-			if data, err = app.Storage.Read(
-				cmd.Context(),
-				api.WithWorkspace(rootConfig.Workspace),
-				api.WithFilters(pushConfig.Filters),
-			); err != nil {
-				return err
+			if rootConfig.MultiWorkspace() {
+				return forEachWorkspace(cmd.Context(), app, func(ctx context.Context, workspace string) error {
+					return push(ctx, app, workspace)
+				})
 			}
 
-			if !pushConfig.NoLocalValidate {
-				if err = app.Validator.Validate(&data); err != nil {
-					return errors.Wrap(err, "failed to validate configuration")
-				}
-			}
+			return push(cmd.Context(), app, rootConfig.Workspace)
+		},
+	}
+	pushConfig struct {
+		DryRun          bool
+		Out             string
+		Mode            string
+		Method          string
+		Filters         []string
+		NoLocalValidate bool
+		Prune           bool
+		ConfirmPrune    bool
+		Snapshot        bool
+		Verify          bool
+		Force           bool
+		OnlyChanged     bool
+	}
+)
 
-			if pushConfig.DryRun {
-				slog.Info("dry run enabled, storing files to disk instead of pushing to server")
+func push(ctx context.Context, app *cac.Application, workspace string) error {
+	var (
+		data      models.Rfc7396PatchOperation
+		positions = provenance.Map{}
+		err       error
+	)
 
-				var (
-					dryStorage storage.Storage
-					constr     = storage.InitServerStorage
-				)
+	if data, err = app.Storage.Read(
+		ctx,
+		api.WithWorkspace(workspace),
+		api.WithFilters(pushConfig.Filters),
+		api.WithProvenance(positions),
+	); err != nil {
+		return err
+	}
 
-				if rootConfig.Tenant {
-					constr = storage.InitTenantStorage
-				}
+	if !pushConfig.NoLocalValidate {
+		if err = app.Validator.Validate(&data); err != nil {
+			return errors.Wrap(positions.Locate(err), "failed to validate configuration")
+		}
+	}
 
-				if dryStorage, err = storage.InitDryStorage(pushConfig.Out, constr); err != nil {
-					return errors.Wrap(err, "failed to initialize dry storage")
-				}
+	if pushConfig.Prune {
+		if err = prune(ctx, app, workspace, data); err != nil {
+			return err
+		}
+	}
 
-				if err = dryStorage.Write(cmd.Context(), data, api.WithWorkspace(rootConfig.Workspace)); err != nil {
-					return errors.Wrap(err, "failed to write configuration")
-				}
+	if pushConfig.DryRun {
+		slog.Info("dry run enabled, storing files to disk instead of pushing to server")
 
-				return nil
-			}
+		var (
+			dryStorage storage.Storage
+			constr     = storage.InitServerStorage
+		)
 
-			if err = app.Client.Write(
-				cmd.Context(),
-				data,
-				api.WithWorkspace(rootConfig.Workspace),
-				api.WithMode(pushConfig.Mode),
-				api.WithMethod(pushConfig.Method),
-			); err != nil {
-				return errors.Wrap(err, "failed to push configuration")
-			}
+		if rootConfig.Tenant {
+			constr = storage.InitTenantStorage
+		}
+
+		if dryStorage, err = storage.InitDryStorage(pushConfig.Out, constr); err != nil {
+			return errors.Wrap(err, "failed to initialize dry storage")
+		}
+
+		if err = dryStorage.Write(ctx, data, api.WithWorkspace(workspace)); err != nil {
+			return errors.Wrap(err, "failed to write configuration")
+		}
+
+		return nil
+	}
+
+	if !pushConfig.Force {
+		var changed models.Rfc7396PatchOperation
 
-			slog.Info("pushed configuration")
+		if changed, err = changedCollections(ctx, app, workspace, data); err != nil {
+			return err
+		}
 
+		if len(changed) == 0 {
+			slog.Info("nothing to push", "workspace", workspace)
 			return nil
-		},
+		}
+
+		if pushConfig.OnlyChanged {
+			data = changed
+		}
 	}
-	pushConfig struct {
-		DryRun     bool
-		Out        string
-		Mode       string
-		Method     string
-		Filters    []string
-		NoLocalValidate bool
+
+	if pushConfig.Snapshot {
+		if err = createSnapshot(ctx, app, workspace); err != nil {
+			return err
+		}
 	}
-)
+
+	if err = app.Client.Write(
+		ctx,
+		data,
+		api.WithWorkspace(workspace),
+		api.WithMode(pushConfig.Mode),
+		api.WithMethod(pushConfig.Method),
+	); err != nil {
+		return errors.Wrap(err, "failed to push configuration")
+	}
+
+	slog.Info("pushed configuration", "workspace", workspace)
+
+	if pushConfig.Verify {
+		if err = verify(ctx, app, workspace, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// verify reads remote configuration after push and fails if anything pushed is not present there
+// or if anything pruned is still present
+func verify(ctx context.Context, app *cac.Application, workspace string, data models.Rfc7396PatchOperation) error {
+	var (
+		local   models.Rfc7396PatchOperation
+		remote  models.Rfc7396PatchOperation
+		deleted []string
+		tree    string
+		result  string
+		err     error
+	)
+
+	if local, err = utils.NormalizePatch(data); err != nil {
+		return err
+	}
+
+	// pruned entities are expected to be missing in the remote configuration
+	deleted = utils.RemoveNulls(local)
+
+	if remote, err = app.Client.Read(
+		ctx,
+		api.WithWorkspace(workspace),
+		api.WithFilters(pushConfig.Filters),
+		api.WithSecrets(true),
+	); err != nil {
+		return errors.Wrap(err, "failed to read remote configuration")
+	}
+
+	for _, pointer := range deleted {
+		if utils.Present(remote, pointer) {
+			result += fmt.Sprintf("not deleted: %s\n", pointer)
+		}
+	}
+
+	// server fills in defaults, so only fields which were pushed are verified
+	onlyPushed(local, remote)
+
+	if tree, err = diff.Tree(
+		local,
+		remote,
+		diff.OnlyPresent(true),
+		diff.FilterVolatileFields(true),
+		diff.WithSecrets(false),
+	); err != nil {
+		return errors.Wrap(err, "failed to compare configurations")
+	}
+
+	result += tree
+
+	if result != "" {
+		if _, err = os.Stdout.Write([]byte(result)); err != nil {
+			return errors.Wrap(err, "failed to write diff result to stdout")
+		}
+
+		return errors.New("remote configuration does not match pushed configuration")
+	}
+
+	slog.Info("verified pushed configuration", "workspace", workspace)
+
+	return nil
+}
+
+// changedCollections returns top level fields of the local configuration which differ from the remote configuration
+func changedCollections(ctx context.Context, app *cac.Application, workspace string, data models.Rfc7396PatchOperation) (models.Rfc7396PatchOperation, error) {
+	var (
+		remote  models.Rfc7396PatchOperation
+		changed models.Rfc7396PatchOperation
+		err     error
+	)
+
+	if pushConfig.OnlyChanged && pushConfig.Method != "patch" {
+		return nil, errors.New("only-changed is supported only with the patch method")
+	}
+
+	// remote is read with secrets, so changes of secrets stored locally are pushed too
+	if remote, err = app.Client.Read(
+		ctx,
+		api.WithWorkspace(workspace),
+		api.WithFilters(pushConfig.Filters),
+		api.WithSecrets(true),
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to read remote configuration")
+	}
+
+	// import replaces remote configuration, so everything missing locally is a change too,
+	// except for secrets which are usually not stored locally
+	if changed, err = diff.ChangedCollections(
+		data,
+		remote,
+		diff.OnlyPresent(pushConfig.Method == "patch"),
+		diff.Filters(pushConfig.Filters...),
+		diff.FilterVolatileFields(true),
+		diff.IgnoreMissingSecrets(true),
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to compare configurations")
+	}
+
+	slog.Debug("changed collections", "workspace", workspace, "collections", slices.Sorted(maps.Keys(changed)))
+
+	return changed, nil
+}
+
+// createSnapshot stores current remote configuration, so it can be restored with the rollback command
+func createSnapshot(ctx context.Context, app *cac.Application, workspace string) error {
+	var (
+		snap *snapshot.Snapshot
+		err  error
+	)
+
+	if snap, err = app.Snapshots.Create(ctx, app.Client, workspace, rootConfig.Tenant); err != nil {
+		return errors.Wrap(err, "failed to create snapshot")
+	}
+
+	if _, err = app.Snapshots.Prune(workspace, rootConfig.Tenant, app.Config.Snapshots.Retention); err != nil {
+		return errors.Wrap(err, "failed to prune snapshots")
+	}
+
+	slog.Info("created snapshot before push", "id", snap.ID)
+
+	return nil
+}
+
+// onlyPushed removes fields missing in the local configuration from the remote configuration
+func onlyPushed(local map[string]any, remote map[string]any) {
+	for k, v := range remote {
+		lv, ok := local[k]
+
+		if !ok {
+			delete(remote, k)
+			continue
+		}
+
+		lm, lok := lv.(map[string]any)
+		rm, rok := v.(map[string]any)
+
+		if lok && rok {
+			onlyPushed(lm, rm)
+		}
+	}
+}
+
+// prune adds deletions of remote entities missing in the local configuration to the patch
+func prune(ctx context.Context, app *cac.Application, workspace string, data models.Rfc7396PatchOperation) error {
+	var (
+		remote    models.Rfc7396PatchOperation
+		deletions []utils.Deletion
+		err       error
+	)
+
+	if pushConfig.Method != "patch" {
+		return errors.New("prune is supported only with the patch method")
+	}
+
+	if remote, err = app.Client.Read(
+		ctx,
+		api.WithWorkspace(workspace),
+		api.WithFilters(pushConfig.Filters),
+	); err != nil {
+		return errors.Wrap(err, "failed to read remote configuration")
+	}
+
+	if rootConfig.Tenant {
+		deletions = utils.PruneTenant(data, remote, pushConfig.Filters)
+	} else {
+		deletions = utils.PruneServer(data, remote, workspace, pushConfig.Filters)
+	}
+
+	if len(deletions) == 0 {
+		slog.Info("nothing to prune")
+		return nil
+	}
+
+	for _, d := range deletions {
+		if _, err = fmt.Fprintf(os.Stdout, "delete %s\n", formatDeletion(d)); err != nil {
+			return errors.Wrap(err, "failed to write deletions to stdout")
+		}
+	}
+
+	if !pushConfig.ConfirmPrune {
+		return errors.Errorf("%d resources would be deleted, rerun with --confirm-prune to proceed", len(deletions))
+	}
+
+	slog.Warn("pruning resources missing in local configuration", "count", len(deletions))
+
+	utils.ApplyDeletions(data, deletions, rootConfig.Tenant)
+
+	return nil
+}
+
+func formatDeletion(d utils.Deletion) string {
+	var out = d.Collection + "/" + d.ID
+
+	if d.Workspace != "" {
+		out = "workspaces/" + d.Workspace + "/" + out
+	}
+
+	if d.Name != "" {
+		out += fmt.Sprintf(" (%s)", d.Name)
+	}
+
+	return out
+}
 
 func init() {
 	pushCmd.PersistentFlags().BoolVar(&pushConfig.DryRun, "dry-run", false, "Write files to disk instead of pushing to server")
@@ -94,6 +364,12 @@ func init() {
 	pushCmd.PersistentFlags().StringVar(&pushConfig.Method, "method", "", "One of patch (merges remote with your config before applying), import (replaces remote with your config)")
 	pushCmd.PersistentFlags().BoolVar(&pushConfig.NoLocalValidate, "no-validate", false, "Temporary workaround to skip local validation, which in some cases does not validate a valid config")
 	pushCmd.PersistentFlags().StringSliceVar(&pushConfig.Filters, "filter", []string{}, "Push only selected resources")
+	pushCmd.PersistentFlags().BoolVar(&pushConfig.Prune, "prune", false, "Delete remote resources missing in local configuration (requires patch method)")
+	pushCmd.PersistentFlags().BoolVar(&pushConfig.Snapshot, "snapshot", false, "Store a snapshot of remote configuration before pushing, it can be restored with rollback command")
+	pushCmd.PersistentFlags().BoolVar(&pushConfig.Verify, "verify", false, "Read remote configuration after push and fail if it does not match pushed configuration")
+	pushCmd.PersistentFlags().BoolVar(&pushConfig.Force, "force", false, "Push even if local configuration does not differ from remote configuration")
+	pushCmd.PersistentFlags().BoolVar(&pushConfig.OnlyChanged, "only-changed", false, "Push only top level collections which differ from remote configuration (requires patch method)")
+	pushCmd.PersistentFlags().BoolVar(&pushConfig.ConfirmPrune, "confirm-prune", false, "Confirm deletion of resources listed by --prune")
 
 	mustMarkRequired(pushCmd, "method")
 }
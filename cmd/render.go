@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac"
+	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/cloudentity/cac/internal/cac/provenance"
+	"github.com/cloudentity/cac/internal/cac/storage"
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+	ccyaml "github.com/goccy/go-yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slog"
+)
+
+var (
+	renderCmd = &cobra.Command{
+		Use:   "render",
+		Short: "Print local configuration with templates rendered, values applied and dir_path layers merged",
+		Long: `Print local configuration with templates rendered, values applied and dir_path layers merged.
+
+The configuration is read the same way as by push, including overlays, so it shows what would be sent to the server.
+With --file only the given template file is rendered. With --sources files of each entity are printed instead,
+in the priority order of dir_path layers.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				app       *cac.Application
+				multi     *storage.MultiStorage
+				data      models.Rfc7396PatchOperation
+				positions provenance.Map
+				out       any
+				bts       []byte
+				ok        bool
+				err       error
+			)
+
+			if err = requireSingleWorkspace(); err != nil {
+				return err
+			}
+
+			if renderConfig.Output != "yaml" && renderConfig.Output != "json" {
+				return errors.Errorf("unsupported output %s, expected one of yaml, json", renderConfig.Output)
+			}
+
+			if app, err = cac.InitLocalApp(rootConfig.ConfigPath, rootConfig.Profile, rootConfig.Tenant, rootConfig.Values...); err != nil {
+				return err
+			}
+
+			if multi, ok = app.Storage.(*storage.MultiStorage); !ok {
+				return errors.New("storage is not configured")
+			}
+
+			slog.
+				With("workspace", rootConfig.Workspace).
+				With("tenant", rootConfig.Tenant).
+				With("file", renderConfig.File).
+				Debug("Rendering configuration")
+
+			if renderConfig.File != "" {
+				if bts, err = multi.RenderFile(renderConfig.File, rootConfig.Workspace); err != nil {
+					return errors.Wrapf(err, "failed to render %s", renderConfig.File)
+				}
+
+				if renderConfig.Output == "yaml" {
+					_, err = cmd.OutOrStdout().Write(bts)
+					return err
+				}
+
+				if err = ccyaml.Unmarshal(bts, &out); err != nil {
+					return errors.Wrapf(err, "failed to parse rendered %s", renderConfig.File)
+				}
+
+				return writeRendered(cmd, out)
+			}
+
+			if renderConfig.Sources {
+				positions = provenance.Map{}
+			}
+
+			if data, err = multi.Read(
+				cmd.Context(),
+				api.WithWorkspace(rootConfig.Workspace),
+				api.WithFilters(renderConfig.Filters),
+				api.WithProvenance(positions),
+			); err != nil {
+				return err
+			}
+
+			if renderConfig.Sources {
+				return writeRendered(cmd, multi.Sources(positions))
+			}
+
+			return writeRendered(cmd, data)
+		},
+	}
+	renderConfig struct {
+		File    string
+		Sources bool
+		Output  string
+		Filters []string
+	}
+)
+
+// writeRendered prints the value in the requested format, keys are sorted, so outputs can be compared
+func writeRendered(cmd *cobra.Command, it any) error {
+	var (
+		bts []byte
+		err error
+	)
+
+	if bts, err = json.Marshal(it, json.Deterministic(true), jsontext.WithIndent("  ")); err != nil {
+		return err
+	}
+
+	switch renderConfig.Output {
+	case "json":
+		bts = append(bts, '\n')
+	default:
+		if bts, err = ccyaml.JSONToYAML(bts); err != nil {
+			return err
+		}
+	}
+
+	_, err = cmd.OutOrStdout().Write(bts)
+
+	return err
+}
+
+func init() {
+	renderCmd.PersistentFlags().StringVar(&renderConfig.File, "file", "", "Render only the given template file")
+	renderCmd.PersistentFlags().BoolVar(&renderConfig.Sources, "sources", false, "Print files each entity is read from, grouped by dir_path layers")
+	renderCmd.PersistentFlags().StringVar(&renderConfig.Output, "output", "yaml", "Output format. One of yaml, json")
+	renderCmd.PersistentFlags().StringSliceVar(&renderConfig.Filters, "filter", []string{}, "Render only selected resources")
+
+	renderCmd.MarkFlagsMutuallyExclusive("file", "sources")
+}
@@ -8,29 +8,51 @@ var (
 	rootCmd = &cobra.Command{
 		Use:   "cac",
 		Short: "Cloudentity configuration manager",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			rootConfig.resolveWorkspace()
+		},
 	}
 	rootConfig = RootConfig{}
 )
 
 type RootConfig struct {
-	ConfigPath string
-	Profile    string
-	Workspace  string
-	Tenant     bool
+	ConfigPath    string
+	Profile       string
+	Workspace     string
+	Workspaces    []string
+	AllWorkspaces bool
+	Concurrency   int
+	Tenant        bool
+	Values        []string
 }
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&rootConfig.ConfigPath, "config", "", "Path to source configuration file")
 	rootCmd.PersistentFlags().StringVar(&rootConfig.Profile, "profile", "", "Configuration profile")
 	rootCmd.PersistentFlags().BoolVar(&rootConfig.Tenant, "tenant", false, "Tenant configuration")
-	rootCmd.PersistentFlags().StringVar(&rootConfig.Workspace, "workspace", "", "Workspace configuration")
+	rootCmd.PersistentFlags().StringSliceVar(&rootConfig.Workspaces, "workspace", []string{}, "Workspace configuration. Pull and push accept multiple workspaces and glob patterns")
+	rootCmd.PersistentFlags().BoolVar(&rootConfig.AllWorkspaces, "all-workspaces", false, "Process all workspaces of the tenant (pull and push only)")
+	rootCmd.PersistentFlags().IntVar(&rootConfig.Concurrency, "concurrency", 4, "Number of workspaces processed concurrently")
+	rootCmd.PersistentFlags().StringSliceVar(&rootConfig.Values, "values", []string{}, "Values files exposed to templates as .Values, merged on top of storage.values")
 
 	rootCmd.AddCommand(pullCmd)
 	rootCmd.AddCommand(pushCmd)
 	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(planCmd)
+	rootCmd.AddCommand(applyCmd)
+	rootCmd.AddCommand(snapshotsCmd)
+	rootCmd.AddCommand(rollbackCmd)
+	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(testCmd)
+	rootCmd.AddCommand(secretsCmd)
+	rootCmd.AddCommand(renderCmd)
 
-	rootCmd.MarkFlagsMutuallyExclusive("workspace", "tenant")
-	rootCmd.MarkFlagsOneRequired("workspace", "tenant")
+	rootCmd.MarkFlagsMutuallyExclusive("workspace", "tenant", "all-workspaces")
+
+	// commands reading or writing configuration of a workspace
+	for _, cmd := range []*cobra.Command{pullCmd, pushCmd, diffCmd, planCmd, renderCmd, snapshotsListCmd, snapshotsPruneCmd, rollbackCmd} {
+		cmd.PreRunE = requireWorkspaceFlags
+	}
 }
 
 func Execute() error {
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudentity/cac/internal/cac"
+	"github.com/cloudentity/cac/internal/cac/secrets"
+	"github.com/cloudentity/cac/internal/cac/storage"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slog"
+)
+
+var (
+	secretsCmd = &cobra.Command{
+		Use:   "secrets",
+		Short: "Manage secrets encrypted with age in configuration files",
+	}
+	secretsEncryptCmd = &cobra.Command{
+		Use:   "encrypt [file]",
+		Short: "Encrypt a value read from the file or stdin for recipients configured in secrets.age.recipients",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				app       *cac.Application
+				bts       []byte
+				encrypted string
+				err       error
+			)
+
+			if app, err = cac.InitLocalApp(rootConfig.ConfigPath, rootConfig.Profile, rootConfig.Tenant); err != nil {
+				return err
+			}
+
+			if bts, err = readInput(cmd, args); err != nil {
+				return err
+			}
+
+			if encrypted, err = ageConfig(app).Encrypt(strings.TrimSuffix(string(bts), "\n")); err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), encrypted)
+
+			return err
+		},
+	}
+	secretsDecryptCmd = &cobra.Command{
+		Use:   "decrypt [file...]",
+		Short: "Print files or stdin with encrypted values decrypted",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				app *cac.Application
+				bts []byte
+				err error
+			)
+
+			if app, err = cac.InitLocalApp(rootConfig.ConfigPath, rootConfig.Profile, rootConfig.Tenant); err != nil {
+				return err
+			}
+
+			if len(args) == 0 {
+				args = []string{"-"}
+			}
+
+			for _, arg := range args {
+				if bts, err = readInput(cmd, []string{arg}); err != nil {
+					return err
+				}
+
+				if bts, err = ageConfig(app).DecryptText(bts); err != nil {
+					return errors.Wrapf(err, "failed to decrypt %s", arg)
+				}
+
+				if _, err = cmd.OutOrStdout().Write(bts); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+	secretsRotateKeyCmd = &cobra.Command{
+		Use:   "rotate-key",
+		Short: "Re-encrypt values in storage files for recipients currently configured in secrets.age.recipients",
+		Long: `Re-encrypt values in storage files for recipients currently configured in secrets.age.recipients.
+
+Encrypted values of all files in storage.dir_path, storage.overlays and storage.values are decrypted
+with configured identities, so the old key has to be listed in secrets.age.identities until rotation completes.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				app   *cac.Application
+				files []string
+				err   error
+			)
+
+			if app, err = cac.InitLocalApp(rootConfig.ConfigPath, rootConfig.Profile, rootConfig.Tenant, rootConfig.Values...); err != nil {
+				return err
+			}
+
+			if files, err = storageFiles(app.Config.Storage); err != nil {
+				return err
+			}
+
+			for _, file := range files {
+				var (
+					bts     []byte
+					rotated []byte
+					info    os.FileInfo
+				)
+
+				if bts, err = os.ReadFile(file); err != nil {
+					return err
+				}
+
+				if rotated, err = ageConfig(app).ReencryptText(bts); err != nil {
+					return errors.Wrapf(err, "failed to re-encrypt %s", file)
+				}
+
+				if string(rotated) == string(bts) {
+					continue
+				}
+
+				if info, err = os.Stat(file); err != nil {
+					return err
+				}
+
+				if err = os.WriteFile(file, rotated, info.Mode()); err != nil {
+					return err
+				}
+
+				slog.Info("re-encrypted secrets", "file", file)
+			}
+
+			return nil
+		},
+	}
+)
+
+// ageConfig returns age keys of the selected profile
+func ageConfig(app *cac.Application) *secrets.AgeConfiguration {
+	if app.Config.Secrets == nil {
+		return nil
+	}
+
+	return app.Config.Secrets.Age
+}
+
+// readInput reads the file given as the only argument, or stdin when there are no arguments or the argument is -
+func readInput(cmd *cobra.Command, args []string) ([]byte, error) {
+	if len(args) == 0 || args[0] == "-" {
+		return io.ReadAll(cmd.InOrStdin())
+	}
+
+	return os.ReadFile(args[0])
+}
+
+// storageFiles returns YAML files of local configuration, including overlays and values files
+func storageFiles(config *storage.MultiStorageConfiguration) ([]string, error) {
+	var files = append([]string{}, config.Values...)
+
+	for _, dir := range append(append([]string{}, config.DirPath...), config.Overlays...) {
+		if err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+
+				return err
+			}
+
+			if ext := filepath.Ext(path); !d.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				files = append(files, path)
+			}
+
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsEncryptCmd)
+	secretsCmd.AddCommand(secretsDecryptCmd)
+	secretsCmd.AddCommand(secretsRotateKeyCmd)
+}
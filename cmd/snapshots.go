@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cloudentity/cac/internal/cac"
+	"github.com/cloudentity/cac/internal/cac/snapshot"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slog"
+)
+
+var (
+	snapshotsCmd = &cobra.Command{
+		Use:   "snapshots",
+		Short: "Manage snapshots of remote configuration created before push",
+	}
+	snapshotsListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List snapshots",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				app       *cac.Application
+				snapshots []*snapshot.Snapshot
+				err       error
+			)
+
+			if err = requireSingleWorkspace(); err != nil {
+				return err
+			}
+
+			if app, err = cac.InitLocalApp(rootConfig.ConfigPath, rootConfig.Profile, rootConfig.Tenant, rootConfig.Values...); err != nil {
+				return err
+			}
+
+			if snapshots, err = app.Snapshots.List(rootConfig.Workspace, rootConfig.Tenant); err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+			if _, err = fmt.Fprintln(w, "ID\tCREATED AT"); err != nil {
+				return err
+			}
+
+			for _, s := range snapshots {
+				if _, err = fmt.Fprintf(w, "%s\t%s\n", s.ID, s.CreatedAt.Format(time.RFC3339)); err != nil {
+					return err
+				}
+			}
+
+			return w.Flush()
+		},
+	}
+	snapshotsPruneCmd = &cobra.Command{
+		Use:   "prune",
+		Short: "Remove the oldest snapshots",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				app     *cac.Application
+				removed []string
+				err     error
+			)
+
+			if err = requireSingleWorkspace(); err != nil {
+				return err
+			}
+
+			if app, err = cac.InitLocalApp(rootConfig.ConfigPath, rootConfig.Profile, rootConfig.Tenant, rootConfig.Values...); err != nil {
+				return err
+			}
+
+			retention := app.Config.Snapshots.Retention
+
+			if cmd.Flags().Changed("retention") {
+				retention = snapshotsConfig.Retention
+			}
+
+			if removed, err = app.Snapshots.Prune(rootConfig.Workspace, rootConfig.Tenant, retention); err != nil {
+				return err
+			}
+
+			slog.Info("pruned snapshots", "removed", removed, "retention", retention)
+
+			return nil
+		},
+	}
+	rollbackCmd = &cobra.Command{
+		Use:   "rollback",
+		Short: "Restore remote configuration from a snapshot",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				app  *cac.Application
+				snap *snapshot.Snapshot
+				err  error
+			)
+
+			if err = requireSingleWorkspace(); err != nil {
+				return err
+			}
+
+			if app, err = cac.InitApp(rootConfig.ConfigPath, rootConfig.Profile, rootConfig.Tenant, rootConfig.Values...); err != nil {
+				return err
+			}
+
+			if snap, err = app.Snapshots.Get(snapshotsConfig.ID); err != nil {
+				return err
+			}
+
+			if snap.Tenant != rootConfig.Tenant || snap.Workspace != rootConfig.Workspace {
+				return errors.Errorf("snapshot was created for workspace %q (tenant: %v)", snap.Workspace, snap.Tenant)
+			}
+
+			slog.
+				With("workspace", snap.Workspace).
+				With("tenant", snap.Tenant).
+				With("snapshot", snap.ID).
+				Info("Restoring snapshot")
+
+			if _, err = app.Snapshots.Restore(cmd.Context(), app.Client, snap.ID, snapshotsConfig.Mode); err != nil {
+				return err
+			}
+
+			slog.Info("restored snapshot", "snapshot", snap.ID)
+
+			return nil
+		},
+	}
+	snapshotsConfig struct {
+		ID        string
+		Mode      string
+		Retention int
+	}
+)
+
+func init() {
+	snapshotsPruneCmd.PersistentFlags().IntVar(&snapshotsConfig.Retention, "retention", 0, "Number of snapshots to keep (default from snapshots.retention config)")
+
+	rollbackCmd.PersistentFlags().StringVar(&snapshotsConfig.ID, "snapshot", "", "Snapshot id")
+	rollbackCmd.PersistentFlags().StringVar(&snapshotsConfig.Mode, "mode", "update", "One of ignore, fail, update")
+
+	mustMarkRequired(rollbackCmd, "snapshot")
+
+	snapshotsCmd.AddCommand(snapshotsListCmd)
+	snapshotsCmd.AddCommand(snapshotsPruneCmd)
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac"
+	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/cloudentity/cac/internal/cac/harness"
+	"github.com/cloudentity/cac/internal/cac/utils"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slog"
+)
+
+var (
+	testCmd = &cobra.Command{
+		Use:   "test",
+		Short: "Test local configuration against fixtures without connecting to the server",
+	}
+	testPoliciesCmd = &cobra.Command{
+		Use:   "policies",
+		Short: "Evaluate policies against fixture inputs and assert allow or deny outcomes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				server  *models.TreeServer
+				suites  map[string]harness.PolicySuite
+				files   []string
+				results []harness.Result
+				runner  *harness.PolicyRunner
+				dir     = filepath.Join(testConfig.Dir, "policies")
+				err     error
+			)
+
+			if server, err = readTestedServer(cmd, "policies"); err != nil {
+				return err
+			}
+
+			if suites, files, err = harness.LoadPolicySuites(dir); err != nil {
+				return err
+			}
+
+			slog.Info("Testing policies", "workspace", rootConfig.Workspace, "tests", dir, "files", len(files))
+
+			runner = &harness.PolicyRunner{}
+			results = runner.Run(server.Policies, suites, files)
+
+			return reportTestResults(cmd, results)
+		},
+	}
+	testScriptsCmd = &cobra.Command{
+		Use:   "scripts",
+		Short: "Execute scripts with mocked contexts and compare their output with expected fixtures",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				server  *models.TreeServer
+				suites  map[string]harness.ScriptSuite
+				files   []string
+				results []harness.Result
+				runner  = &harness.ScriptRunner{Timeout: testConfig.Timeout}
+				dir     = filepath.Join(testConfig.Dir, "scripts")
+				err     error
+			)
+
+			if server, err = readTestedServer(cmd, "scripts", "script_execution_points"); err != nil {
+				return err
+			}
+
+			if suites, files, err = harness.LoadScriptSuites(dir); err != nil {
+				return err
+			}
+
+			slog.Info("Testing scripts", "workspace", rootConfig.Workspace, "tests", dir, "files", len(files))
+
+			results = runner.Run(server, suites, files)
+
+			return reportTestResults(cmd, results)
+		},
+	}
+	testConfig struct {
+		Dir     string
+		Timeout time.Duration
+	}
+)
+
+// readTestedServer reads collections of the workspace from local storage
+func readTestedServer(cmd *cobra.Command, collections ...string) (*models.TreeServer, error) {
+	var (
+		app  *cac.Application
+		data models.Rfc7396PatchOperation
+		err  error
+	)
+
+	if err = requireSingleWorkspace(); err != nil {
+		return nil, err
+	}
+
+	if rootConfig.Tenant {
+		return nil, errors.Errorf("%s are tested per workspace", collections[0])
+	}
+
+	if app, err = cac.InitLocalApp(rootConfig.ConfigPath, rootConfig.Profile, rootConfig.Tenant, rootConfig.Values...); err != nil {
+		return nil, err
+	}
+
+	if data, err = app.Storage.Read(
+		cmd.Context(),
+		api.WithWorkspace(rootConfig.Workspace),
+		api.WithFilters(collections),
+	); err != nil {
+		return nil, err
+	}
+
+	server, err := utils.FromPatchToModel[models.TreeServer](data)
+
+	return server, err
+}
+
+func reportTestResults(cmd *cobra.Command, results []harness.Result) error {
+	if err := harness.WriteResults(os.Stdout, results); err != nil {
+		return errors.Wrap(err, "failed to write test results")
+	}
+
+	if failed := harness.Failed(results); failed > 0 {
+		cmd.SilenceUsage = true
+		return &ExitError{Code: 1, Err: errors.Errorf("%d of %d test cases failed", failed, len(results))}
+	}
+
+	return nil
+}
+
+func init() {
+	testCmd.PersistentFlags().StringVar(&testConfig.Dir, "dir", "tests", "Directory with test files, tests are read from its policies and scripts subdirectories")
+	testScriptsCmd.PersistentFlags().DurationVar(&testConfig.Timeout, "timeout", harness.DefaultScriptTimeout, "Maximum execution time of a single script test case")
+
+	testCmd.AddCommand(testPoliciesCmd)
+	testCmd.AddCommand(testScriptsCmd)
+}
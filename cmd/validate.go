@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac"
+	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/cloudentity/cac/internal/cac/lint"
+	"github.com/cloudentity/cac/internal/cac/provenance"
+	"github.com/cloudentity/cac/internal/cac/report"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slog"
+)
+
+var (
+	validateCmd = &cobra.Command{
+		Use:   "validate",
+		Short: "Validate local configuration without connecting to the server",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				app       *cac.Application
+				data      models.Rfc7396PatchOperation
+				format    report.Format
+				positions = provenance.Map{}
+				rep       = &report.Report{}
+				out       = io.Writer(os.Stdout)
+				err       error
+			)
+
+			if err = requireSingleWorkspace(); err != nil {
+				return err
+			}
+
+			if format, err = report.FormatFromString(validateConfig.Output); err != nil {
+				return err
+			}
+
+			if app, err = cac.InitLocalApp(rootConfig.ConfigPath, rootConfig.Profile, rootConfig.Tenant, rootConfig.Values...); err != nil {
+				return err
+			}
+
+			slog.
+				With("workspace", rootConfig.Workspace).
+				With("tenant", rootConfig.Tenant).
+				With("filters", validateConfig.Filters).
+				With("output", validateConfig.Output).
+				Info("Validating configuration")
+
+			// configuration which can't be read is reported as an issue too, so CI gets a report in any case
+			if data, err = app.Storage.Read(
+				cmd.Context(),
+				api.WithWorkspace(rootConfig.Workspace),
+				api.WithFilters(validateConfig.Filters),
+				api.WithProvenance(positions),
+			); err != nil {
+				rep.AddError("storage", report.SeverityError, err, positions)
+			} else if err = validate(app, data, positions, rep); err != nil {
+				return err
+			}
+
+			rep.Sort()
+
+			if validateConfig.Out != "-" {
+				var f *os.File
+
+				if f, err = os.Create(validateConfig.Out); err != nil {
+					return errors.Wrap(err, "failed to create report file")
+				}
+
+				defer f.Close()
+
+				out = f
+			}
+
+			if err = rep.Write(out, format, "cac validate"); err != nil {
+				return errors.Wrap(err, "failed to write report")
+			}
+
+			if rep.Count(report.SeverityError) > 0 {
+				cmd.SilenceUsage = true
+				return &ExitError{Code: 1, Err: errors.Errorf("configuration is invalid, found %d errors", rep.Count(report.SeverityError))}
+			}
+
+			return nil
+		},
+	}
+	validateConfig struct {
+		Filters []string
+		Output  string
+		Out     string
+		NoLint  bool
+	}
+)
+
+// validate runs all checks of the configuration and collects their issues in the report
+func validate(app *cac.Application, data models.Rfc7396PatchOperation, positions provenance.Map, rep *report.Report) error {
+	var (
+		linter *lint.Linter
+		err    error
+	)
+
+	if err = app.Validator.Validate(&data); err != nil {
+		rep.AddError(report.RuleValidation, report.SeverityError, err, positions)
+	}
+
+	if validateConfig.NoLint {
+		return nil
+	}
+
+	if linter, err = lint.New(app.Config.Lint); err != nil {
+		return err
+	}
+
+	// configuration which can't be decoded is already reported by validation
+	if err = linter.Lint(data, rootConfig.Tenant, positions, rep); err != nil {
+		slog.Warn("skipped linting, configuration can't be decoded", "error", err)
+	}
+
+	return nil
+}
+
+func init() {
+	validateCmd.PersistentFlags().StringSliceVar(&validateConfig.Filters, "filter", []string{}, "Validate only selected resources")
+	validateCmd.PersistentFlags().StringVar(&validateConfig.Output, "output", "text", "Report format. One of text, json, junit")
+	validateCmd.PersistentFlags().StringVar(&validateConfig.Out, "out", "-", "Report output. It can be a file or '-' for stdout")
+	validateCmd.PersistentFlags().BoolVar(&validateConfig.NoLint, "no-lint", false, "Skip lint rules")
+}
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/cloudentity/cac/internal/cac"
+	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slog"
+)
+
+var ErrMultipleWorkspaces = errors.New("command supports a single workspace only")
+
+type workspaceResult struct {
+	Workspace string
+	Err       error
+}
+
+// MultiWorkspace returns true when more than one workspace or a workspace pattern was requested
+func (r *RootConfig) MultiWorkspace() bool {
+	return r.AllWorkspaces || len(r.Workspaces) > 1 || (len(r.Workspaces) == 1 && isPattern(r.Workspaces[0]))
+}
+
+// resolveWorkspace sets the workspace used by single workspace commands
+func (r *RootConfig) resolveWorkspace() {
+	if !r.MultiWorkspace() && len(r.Workspaces) == 1 {
+		r.Workspace = r.Workspaces[0]
+	}
+}
+
+func requireSingleWorkspace() error {
+	if rootConfig.MultiWorkspace() {
+		return ErrMultipleWorkspaces
+	}
+
+	return nil
+}
+
+func isPattern(workspace string) bool {
+	return strings.ContainsAny(workspace, "*?[")
+}
+
+// matchWorkspaces returns workspaces matching requested names and glob patterns
+func matchWorkspaces(ctx context.Context, source api.Source) ([]string, error) {
+	var (
+		available []string
+		out       []string
+		seen      = map[string]bool{}
+		listed    bool
+		err       error
+	)
+
+	list := func() error {
+		if listed {
+			return nil
+		}
+
+		lister, ok := source.(api.WorkspaceLister)
+
+		if !ok {
+			return errors.Errorf("%s does not support listing workspaces", source)
+		}
+
+		if available, err = lister.Workspaces(ctx); err != nil {
+			return err
+		}
+
+		listed = true
+
+		return nil
+	}
+
+	if rootConfig.AllWorkspaces {
+		if err = list(); err != nil {
+			return nil, err
+		}
+
+		return available, nil
+	}
+
+	for _, w := range rootConfig.Workspaces {
+		if !isPattern(w) {
+			if !seen[w] {
+				seen[w] = true
+				out = append(out, w)
+			}
+
+			continue
+		}
+
+		if err = list(); err != nil {
+			return nil, err
+		}
+
+		for _, a := range available {
+			var matched bool
+
+			if matched, err = path.Match(w, a); err != nil {
+				return nil, errors.Wrapf(err, "invalid workspace pattern %s", w)
+			}
+
+			if matched && !seen[a] {
+				seen[a] = true
+				out = append(out, a)
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// forEachWorkspace runs fn for every requested workspace with limited concurrency and prints a summary
+func forEachWorkspace(ctx context.Context, app *cac.Application, fn func(ctx context.Context, workspace string) error) error {
+	var (
+		workspaces []string
+		results    []workspaceResult
+		failed     int
+		wg         sync.WaitGroup
+		sem        = make(chan struct{}, max(rootConfig.Concurrency, 1))
+		err        error
+	)
+
+	if workspaces, err = matchWorkspaces(ctx, app.Client); err != nil {
+		return err
+	}
+
+	if len(workspaces) == 0 {
+		return errors.New("no workspaces matched")
+	}
+
+	slog.Info("Processing workspaces", "workspaces", workspaces, "concurrency", rootConfig.Concurrency)
+
+	results = make([]workspaceResult, len(workspaces))
+
+	for i, workspace := range workspaces {
+		wg.Add(1)
+
+		go func(i int, workspace string) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i] = workspaceResult{
+				Workspace: workspace,
+				Err:       fn(ctx, workspace),
+			}
+
+			if results[i].Err != nil {
+				slog.Error("failed to process workspace", "workspace", workspace, "error", results[i].Err)
+			}
+		}(i, workspace)
+	}
+
+	wg.Wait()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	for _, r := range results {
+		status := "ok"
+
+		if r.Err != nil {
+			status = "failed: " + r.Err.Error()
+			failed++
+		}
+
+		if _, err = fmt.Fprintf(w, "%s\t%s\n", r.Workspace, status); err != nil {
+			return err
+		}
+	}
+
+	if err = w.Flush(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return errors.Errorf("%d of %d workspaces failed", failed, len(results))
+	}
+
+	return nil
+}
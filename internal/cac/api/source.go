@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/provenance"
 )
 
 type SourceType string
@@ -32,6 +33,10 @@ type Options struct {
 	Method    string
 	Filters   []string
 	Workspace string
+	// Provenance when set is filled by sources reading files with positions of read values
+	Provenance provenance.Map
+	// Encrypted makes file based sources return encrypted values as they are stored, without decrypting them
+	Encrypted bool
 }
 
 type SourceOpt func(*Options)
@@ -43,11 +48,30 @@ type Source interface {
 	String() string
 }
 
+// WorkspaceLister is implemented by sources which are able to list available workspaces
+type WorkspaceLister interface {
+	Workspaces(ctx context.Context) ([]string, error)
+}
+
 type Mapper[T any] interface {
 	FromPatchToModel(patch models.Rfc7396PatchOperation) (*T, error)
 	FromModelToPatch(*T) (models.Rfc7396PatchOperation, error)
 }
 
+// WithProvenance makes file based sources record positions of read values
+func WithProvenance(m provenance.Map) SourceOpt {
+	return func(o *Options) {
+		o.Provenance = m
+	}
+}
+
+// WithEncrypted makes file based sources keep encrypted values encrypted
+func WithEncrypted(encrypted bool) SourceOpt {
+	return func(o *Options) {
+		o.Encrypted = encrypted
+	}
+}
+
 func WithSecrets(secrets bool) SourceOpt {
 	return func(o *Options) {
 		o.Secrets = secrets
@@ -6,8 +6,12 @@ import (
 	"github.com/cloudentity/cac/internal/cac/config"
 	"github.com/cloudentity/cac/internal/cac/data"
 	"github.com/cloudentity/cac/internal/cac/logging"
+	"github.com/cloudentity/cac/internal/cac/rego"
+	"github.com/cloudentity/cac/internal/cac/secrets"
+	"github.com/cloudentity/cac/internal/cac/snapshot"
 	"github.com/cloudentity/cac/internal/cac/storage"
 	"golang.org/x/exp/slog"
+	"slices"
 	"strings"
 )
 
@@ -17,10 +21,23 @@ type Application struct {
 	Client     api.Source
 	Storage    storage.Storage
 	Validator  data.ValidatorApi
+	Snapshots  *snapshot.Store
+	// Values are values files merged on top of storage.values of every profile
+	Values []string
 }
 
-func InitApp(configPath string, profile string, tenant bool) (app *Application, err error) {
-	app = &Application{}
+func InitApp(configPath string, profile string, tenant bool, values ...string) (app *Application, err error) {
+	return initApp(configPath, profile, tenant, true, values)
+}
+
+// InitLocalApp initiates the application without the client, so commands working on local configuration only
+// do not require server credentials
+func InitLocalApp(configPath string, profile string, tenant bool, values ...string) (app *Application, err error) {
+	return initApp(configPath, profile, tenant, false, values)
+}
+
+func initApp(configPath string, profile string, tenant bool, withClient bool, values []string) (app *Application, err error) {
+	app = &Application{Values: values}
 
 	if app.RootConfig, err = config.InitConfig(configPath); err != nil {
 		return app, err
@@ -34,9 +51,13 @@ func InitApp(configPath string, profile string, tenant bool) (app *Application,
 		return app, err
 	}
 
+	if err = secrets.InitSecrets(app.Config.Secrets); err != nil {
+		return app, err
+	}
+
 	slog.Debug("config", "c", app.Config.Client)
 
-	if app.Config.Client != nil {
+	if withClient && app.Config.Client != nil {
 		var c *client.Client
 		if c, err = client.InitClient(app.Config.Client); err != nil {
 			return app, err
@@ -51,19 +72,34 @@ func InitApp(configPath string, profile string, tenant bool) (app *Application,
 
 	var constructor = storage.InitServerStorage
 
-	app.Validator = &data.ServerValidator{}
+	// reference and rego validators go first, as model validation cleans the workspace id from the configuration
+	app.Validator = data.Validators{
+		&data.ReferenceValidator{},
+		&rego.Validator{Config: app.Config.Rego},
+		&data.SyntaxValidator{},
+		&data.ServerValidator{},
+	}
 
 	if tenant {
 		constructor = storage.InitTenantStorage
-		app.Validator = &data.TenantValidator{}
+		app.Validator = data.Validators{
+			&data.ReferenceValidator{Tenant: true},
+			&rego.Validator{Config: app.Config.Rego},
+			&data.SyntaxValidator{Tenant: true},
+			&data.TenantValidator{},
+		}
 	}
 
 	if app.Config.Storage != nil {
-		if app.Storage, err = storage.InitMultiStorage(app.Config.Storage, constructor); err != nil {
+		if app.Storage, err = storage.InitMultiStorage(app.storageConfig(app.Config), constructor); err != nil {
 			return app, err
 		}
 	}
 
+	if app.Config.Snapshots != nil {
+		app.Snapshots = snapshot.InitStore(app.Config.Snapshots)
+	}
+
 	slog.Info("Initiated application")
 
 	return app, nil
@@ -101,7 +137,7 @@ func (a *Application) PickSource(source string, tenant bool) (api.Source, error)
 
 	switch sourceType {
 	case api.SourceLocal:
-		return storage.InitMultiStorage(conf.Storage, constructor)
+		return storage.InitMultiStorage(a.storageConfig(conf), constructor)
 	case api.SourceRemote:
 		var (
 			c   *client.Client
@@ -121,3 +157,16 @@ func (a *Application) PickSource(source string, tenant bool) (api.Source, error)
 
 	return nil, api.ErrUnknownSource
 }
+
+// storageConfig returns the storage configuration of the profile with values files of the application and age keys of the profile
+// the configuration is copied, as profiles share the storage configuration of the default profile
+func (a *Application) storageConfig(conf *config.Configuration) *storage.MultiStorageConfiguration {
+	c := *conf.Storage
+	c.Values = append(slices.Clone(c.Values), a.Values...)
+
+	if conf.Secrets != nil {
+		c.Age = conf.Secrets.Age
+	}
+
+	return &c
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"github.com/cloudentity/acp-client-go"
+	"github.com/cloudentity/acp-client-go/clients/admin/client/workspaces"
 	"github.com/cloudentity/acp-client-go/clients/hub/client/workspace_configuration"
 	"github.com/cloudentity/acp-client-go/clients/hub/models"
 	"github.com/cloudentity/cac/internal/cac/api"
@@ -12,6 +13,7 @@ import (
 	"github.com/pkg/errors"
 	"golang.org/x/exp/slog"
 	"net/http"
+	"sort"
 )
 
 type Client struct {
@@ -19,6 +21,7 @@ type Client struct {
 }
 
 var _ api.Source = &Client{}
+var _ api.WorkspaceLister = &Client{}
 
 func InitClient(config *Configuration) (c *Client, err error) {
 	var (
@@ -160,6 +163,43 @@ func (c *Client) Import(ctx context.Context, workspace string, mode string, data
 	return nil
 }
 
+// Workspaces lists ids of tenant workspaces page by page
+func (c *Client) Workspaces(ctx context.Context) ([]string, error) {
+	var (
+		limit = int64(100)
+		after *string
+		out   []string
+	)
+
+	for {
+		var (
+			ok  *workspaces.ListWorkspacesOK
+			err error
+		)
+
+		if ok, err = c.acp.Admin.Workspaces.ListWorkspaces(workspaces.NewListWorkspacesParamsWithContext(ctx).
+			WithLimit(&limit).
+			WithAfterWorkspaceID(after), nil,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to list workspaces")
+		}
+
+		for _, w := range ok.Payload.Workspaces {
+			out = append(out, w.ID)
+		}
+
+		if int64(len(ok.Payload.Workspaces)) < limit {
+			break
+		}
+
+		after = &ok.Payload.Workspaces[len(ok.Payload.Workspaces)-1].ID
+	}
+
+	sort.Strings(out)
+
+	return out, nil
+}
+
 func (c *Client) Tenant() *TenantClient {
 	return &TenantClient{
 		acp: c.acp,
@@ -177,4 +177,25 @@ func TestClient(t *testing.T) {
 		secret := data["servers"].(map[string]interface{})["server1"].(map[string]interface{})["clients"].(map[string]interface{})["cid1"].(map[string]interface{})["client_secret"]
 		require.Equal(t, "secret", secret)
 	})
+
+	t.Run("client lists workspaces", func(t *testing.T) {
+		testServer := CreateMockServer(t)
+		issuer, _ := url.Parse(fmt.Sprintf("%s/postmance/system", testServer.URL))
+		c, err := client.InitClient(&client.Configuration{
+			Insecure: true,
+			Config: acpclient.Config{
+				IssuerURL:    issuer,
+				TenantID:    "postmance",
+				ClientID:     "fb346c287c4d4e378cbae39aa0c3fe52",
+				ClientSecret: "valid_secret",
+			},
+		})
+
+		require.NoError(t, err)
+
+		workspaces, err := c.Workspaces(context.Background())
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"server1"}, workspaces)
+	})
 }
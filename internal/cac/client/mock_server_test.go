@@ -42,6 +42,15 @@ func CreateMockServer(t *testing.T) *httptest.Server {
 		return
 	}
 
+	if req.URL.Path == "/api/admin/postmance/workspaces" {
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusOK)
+		_, err := res.Write([]byte(`{"workspaces": [{"id": "server1"}]}`))
+		require.NoError(t, err)
+
+		return
+	}
+
 	if req.URL.Path == "/api/hub/postmance/promote/config" {
 		res.Header().Set("Content-Type", "application/json")
 		res.WriteHeader(http.StatusOK)
@@ -1,10 +1,15 @@
 package config
 
 import (
+	"path/filepath"
 	"strings"
 
 	"github.com/cloudentity/cac/internal/cac/client"
+	"github.com/cloudentity/cac/internal/cac/lint"
 	"github.com/cloudentity/cac/internal/cac/logging"
+	"github.com/cloudentity/cac/internal/cac/rego"
+	"github.com/cloudentity/cac/internal/cac/secrets"
+	"github.com/cloudentity/cac/internal/cac/snapshot"
 	"github.com/cloudentity/cac/internal/cac/storage"
 	"github.com/cloudentity/cac/internal/cac/utils"
 	"github.com/mitchellh/mapstructure"
@@ -16,9 +21,13 @@ import (
 var (
 	DefaultConfig = func() Configuration {
 		return Configuration{
-			Client:  client.DefaultConfig(),
-			Storage: storage.DefaultMultiStorageConfig(),
-			Logging: logging.DefaultLoggingConfig(),
+			Client:    client.DefaultConfig(),
+			Storage:   storage.DefaultMultiStorageConfig(),
+			Logging:   logging.DefaultLoggingConfig(),
+			Snapshots: snapshot.DefaultConfig(),
+			Lint:      lint.DefaultConfig(),
+			Rego:      rego.DefaultConfig(),
+			Secrets:   secrets.DefaultConfig(),
 		}
 	}
 )
@@ -45,10 +54,14 @@ func (c *RootConfiguration) ForProfile(profile string) (*Configuration, error) {
 }
 
 type Configuration struct {
-	Name    string                             `json:"name"`
-	Logging *logging.Configuration             `json:"logging"`
-	Client  *client.Configuration              `json:"client"`
-	Storage *storage.MultiStorageConfiguration `json:"storage"`
+	Name      string                             `json:"name"`
+	Logging   *logging.Configuration             `json:"logging"`
+	Client    *client.Configuration              `json:"client"`
+	Storage   *storage.MultiStorageConfiguration `json:"storage"`
+	Snapshots *snapshot.Configuration            `json:"snapshots"`
+	Lint      *lint.Configuration                `json:"lint"`
+	Rego      *rego.Configuration                `json:"rego"`
+	Secrets   *secrets.Configuration             `json:"secrets"`
 }
 
 func (c *Configuration) SetImplicitValues(name string, defaultConfig Configuration) {
@@ -67,6 +80,22 @@ func (c *Configuration) SetImplicitValues(name string, defaultConfig Configurati
 	if c.Storage == nil {
 		c.Storage = defaultConfig.Storage
 	}
+
+	if c.Snapshots == nil {
+		c.Snapshots = defaultConfig.Snapshots
+	}
+
+	if c.Lint == nil {
+		c.Lint = defaultConfig.Lint
+	}
+
+	if c.Rego == nil {
+		c.Rego = defaultConfig.Rego
+	}
+
+	if c.Secrets == nil {
+		c.Secrets = defaultConfig.Secrets
+	}
 }
 
 func InitConfig(path string) (_ *RootConfiguration, err error) {
@@ -112,9 +141,25 @@ func InitConfig(path string) (_ *RootConfiguration, err error) {
 
 	slog.With("config", config).Debug("Initiated configuration")
 
+	if path != "" {
+		config.resolvePaths(filepath.Dir(path))
+	}
+
 	for name, profile := range config.Profiles {
 		profile.SetImplicitValues(name, config.Default)
 	}
 
 	return config, nil
 }
+
+// resolvePaths resolves relative paths of the configuration against the directory of the configuration file
+// profiles without own rego configuration share the configuration of the default profile, so it is resolved once
+func (c *RootConfiguration) resolvePaths(dir string) {
+	c.Default.Rego.ResolvePaths(dir)
+
+	for _, profile := range c.Profiles {
+		if profile.Rego != c.Default.Rego {
+			profile.Rego.ResolvePaths(dir)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"github.com/cloudentity/cac/internal/cac/config"
 	"github.com/stretchr/testify/require"
 	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -34,6 +36,18 @@ func TestReadingConfiguration(t *testing.T) {
 		require.NotEmpty(t, "aaaaaaaaaaaaa", profile.Client.ClientID)
 	})
 
+	t.Run("resolves rules directory against configuration file", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "config.yaml")
+
+		require.NoError(t, os.WriteFile(path, []byte("rego:\n  dir_path: policies\nprofiles:\n  abs:\n    rego:\n      dir_path: /etc/rules\n"), 0600))
+
+		rootConf, err := config.InitConfig(path)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(dir, "policies"), rootConf.Default.Rego.DirPath)
+		require.Equal(t, "/etc/rules", rootConf.Profiles["abs"].Rego.DirPath)
+	})
+
 	t.Run("fail on invalid path", func(t *testing.T) {
 		_, err := config.InitConfig("./invalid.json")
 		require.Error(t, err)
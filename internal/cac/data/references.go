@@ -0,0 +1,392 @@
+package data
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/storage"
+	"github.com/cloudentity/cac/internal/cac/utils"
+)
+
+// DanglingReference is a reference to an entity which does not exist in the configuration
+type DanglingReference struct {
+	// File is the storage file, relative to the storage root, the reference comes from
+	File string `json:"file"`
+	// Path is a JSON pointer (RFC 6901) of the referencing field in the configuration
+	Path  string `json:"pointer"`
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+func (r DanglingReference) String() string {
+	return fmt.Sprintf("%s: %s", r.File, r.Error())
+}
+
+// Error describes the reference without the file, so it can be located using provenance
+func (r DanglingReference) Error() string {
+	return fmt.Sprintf("%s references missing %s %q", r.Path, r.Kind, r.Value)
+}
+
+func (r DanglingReference) Pointer() string {
+	return r.Path
+}
+
+type ReferenceError struct {
+	References []DanglingReference
+}
+
+func (e *ReferenceError) Error() string {
+	var lines = make([]string, 0, len(e.References))
+
+	for _, r := range e.References {
+		lines = append(lines, r.String())
+	}
+
+	return fmt.Sprintf("found %d dangling references:\n%s", len(e.References), strings.Join(lines, "\n"))
+}
+
+func (e *ReferenceError) Unwrap() []error {
+	var errs = make([]error, 0, len(e.References))
+
+	for _, r := range e.References {
+		errs = append(errs, r)
+	}
+
+	return errs
+}
+
+// ReferenceValidator checks that entities referenced by ID or name exist in the configuration
+// references are checked only when the referenced collection is present, so partial (filtered) configurations can be validated
+type ReferenceValidator struct {
+	Tenant bool
+}
+
+var _ ValidatorApi = &ReferenceValidator{}
+
+func (rv *ReferenceValidator) Validate(data *models.Rfc7396PatchOperation) error {
+	var (
+		normalized models.Rfc7396PatchOperation
+		refs       []DanglingReference
+		err        error
+	)
+
+	// storage may contain typed models, so the configuration is normalized to plain maps
+	if normalized, err = utils.NormalizePatch(*data); err != nil {
+		return err
+	}
+
+	if rv.Tenant {
+		refs = TenantReferences(normalized)
+	} else {
+		workspace, _ := normalized["id"].(string)
+		refs = ServerReferences(normalized, workspace)
+	}
+
+	if len(refs) > 0 {
+		return &ReferenceError{References: refs}
+	}
+
+	return nil
+}
+
+// TenantReferences returns dangling references of all workspaces of the tenant configuration
+// the configuration is expected to be normalized
+func TenantReferences(tenant models.Rfc7396PatchOperation) []DanglingReference {
+	var (
+		servers                 = utils.AsMap(tenant["servers"])
+		themes, themesPresent   = themeIDs(tenant["themes"])
+		serverIDs, serversFound = ids(tenant["servers"])
+		out                     []DanglingReference
+	)
+
+	for _, workspace := range sortedKeys(servers) {
+		var (
+			server = utils.AsMap(servers[workspace])
+			c      = newChecker(server, workspace, "/servers/"+escape(workspace))
+		)
+
+		c.checkServer()
+
+		if binding := utils.AsMap(server["theme_binding"]); themesPresent {
+			c.check("theme", themes, storage.CollectionFile("theme_binding"), c.pointer("theme_binding", "theme_id"), binding["theme_id"])
+		}
+
+		if serversFound {
+			for _, id := range sortedKeys(utils.AsMap(server["servers_bindings"])) {
+				c.check("workspace", serverIDs, storage.CollectionFile("servers_bindings"), c.pointer("servers_bindings", id), id)
+			}
+		}
+
+		out = append(out, c.out...)
+	}
+
+	return out
+}
+
+// ServerReferences returns dangling references of the workspace configuration
+// theme and server bindings reference tenant entities, so they are checked only as a part of the tenant configuration
+// the configuration is expected to be normalized
+func ServerReferences(server models.Rfc7396PatchOperation, workspace string) []DanglingReference {
+	c := newChecker(server, workspace, "")
+	c.checkServer()
+	return c.out
+}
+
+type set map[string]struct{}
+
+// systemScopes are defined by the authorization server itself, so exported workspaces
+// reference them without declaring them in scopes_without_service or services
+var systemScopes = []string{
+	"openid",
+	"profile",
+	"email",
+	"address",
+	"phone",
+	"offline_access",
+	"introspect_tokens",
+	"revoke_tokens",
+	"revoke_client_access",
+	"manage_logins",
+	"manage_configuration",
+	"view_analytics",
+}
+
+type checker struct {
+	server        map[string]any
+	dir           string
+	prefix        string
+	policies      set
+	policiesFound bool
+	scripts       set
+	scriptsFound  bool
+	scopes        set
+	scopesFound   bool
+	services      set
+	servicesFound bool
+	gateways      set
+	gatewaysFound bool
+	out           []DanglingReference
+}
+
+func newChecker(server map[string]any, workspace string, prefix string) *checker {
+	var c = &checker{
+		server: server,
+		dir:    storage.WorkspaceDir(workspace),
+		prefix: prefix,
+		scopes: set{},
+	}
+
+	for _, name := range systemScopes {
+		c.scopes[name] = struct{}{}
+	}
+
+	c.policies, c.policiesFound = ids(server["policies"])
+	c.scripts, c.scriptsFound = ids(server["scripts"])
+	c.services, c.servicesFound = ids(server["services"])
+	c.gateways, c.gatewaysFound = ids(server["gateways"])
+
+	if scopes, ok := ids(server["scopes_without_service"]); ok {
+		c.scopesFound = true
+
+		for name := range scopes {
+			c.scopes[name] = struct{}{}
+		}
+	}
+
+	for _, service := range utils.AsMap(server["services"]) {
+		if scopes, ok := ids(utils.AsMap(service)["scopes"]); ok {
+			c.scopesFound = true
+
+			for name := range scopes {
+				c.scopes[name] = struct{}{}
+			}
+		}
+	}
+
+	return c
+}
+
+func (c *checker) checkServer() {
+	var (
+		peps   = storage.CollectionFile("policy_execution_points")
+		scopes = storage.CollectionFile("scopes_without_service")
+		seps   = storage.CollectionFile("script_execution_points")
+	)
+
+	if c.policiesFound {
+		c.checkPolicyExecutionPoints(peps, c.server["policy_execution_points"], "policy_execution_points")
+
+		for _, name := range sortedKeys(utils.AsMap(c.server["scopes_without_service"])) {
+			scope := utils.AsMap(utils.AsMap(c.server["scopes_without_service"])[name])
+			c.checkPolicyExecutionPoints(scopes, scope["policy_execution_points"], "scopes_without_service", name, "policy_execution_points")
+		}
+	}
+
+	c.forEach("clients", func(file string, id string, client map[string]any) {
+		if c.policiesFound {
+			c.checkPolicyExecutionPoints(file, client["policy_execution_points"], "clients", id, "policy_execution_points")
+		}
+
+		if c.scopesFound {
+			for i, scope := range asSlice(client["scopes"]) {
+				c.check("scope", c.scopes, file, c.pointer("clients", id, "scopes", fmt.Sprint(i)), scope)
+			}
+		}
+	})
+
+	c.forEach("services", func(file string, id string, service map[string]any) {
+		if c.gatewaysFound {
+			c.check("gateway", c.gateways, file, c.pointer("services", id, "gateway_id"), service["gateway_id"])
+		}
+
+		if !c.policiesFound {
+			return
+		}
+
+		for _, name := range sortedKeys(utils.AsMap(service["scopes"])) {
+			scope := utils.AsMap(utils.AsMap(service["scopes"])[name])
+			c.checkPolicyExecutionPoints(file, scope["policy_execution_points"], "services", id, "scopes", name, "policy_execution_points")
+		}
+
+		for _, api := range sortedKeys(utils.AsMap(service["apis"])) {
+			c.check("policy", c.policies, file, c.pointer("services", id, "apis", api, "policy_id"), utils.AsMap(utils.AsMap(service["apis"])[api])["policy_id"])
+		}
+	})
+
+	c.forEach("gateways", func(file string, id string, gateway map[string]any) {
+		if c.policiesFound {
+			c.check("policy", c.policies, file, c.pointer("gateways", id, "default_policy_id"), gateway["default_policy_id"])
+		}
+
+		if c.servicesFound {
+			groups := utils.AsMap(gateway["gateway_api_groups"])
+
+			for _, group := range sortedKeys(groups) {
+				c.check("service", c.services, file, c.pointer("gateways", id, "gateway_api_groups", group, "service_id"), utils.AsMap(groups[group])["service_id"])
+			}
+		}
+	})
+
+	if c.scriptsFound {
+		points := utils.AsMap(c.server["script_execution_points"])
+
+		for _, typ := range sortedKeys(points) {
+			targets := utils.AsMap(points[typ])
+
+			for _, target := range sortedKeys(targets) {
+				// empty script id means that the script execution point is being deleted
+				c.check("script", c.scripts, seps, c.pointer("script_execution_points", typ, target, "script_id"), utils.AsMap(targets[target])["script_id"])
+			}
+		}
+	}
+}
+
+// forEach calls fn for every entity of the collection, sorted by id
+func (c *checker) forEach(collection string, fn func(file string, id string, entity map[string]any)) {
+	var entities = utils.AsMap(c.server[collection])
+
+	for _, id := range sortedKeys(entities) {
+		entity := utils.AsMap(entities[id])
+		fn(storage.EntityFile(collection, id, entity), id, entity)
+	}
+}
+
+func (c *checker) checkPolicyExecutionPoints(file string, points any, path ...string) {
+	var peps = utils.AsMap(points)
+
+	for _, k := range sortedKeys(peps) {
+		c.check("policy", c.policies, file, c.pointer(append(path, k)...), peps[k])
+	}
+}
+
+// check records a dangling reference if the value is a non-empty string missing in known ids
+func (c *checker) check(kind string, known set, file string, pointer string, value any) {
+	var id, ok = value.(string)
+
+	if !ok || id == "" {
+		return
+	}
+
+	if _, found := known[id]; found {
+		return
+	}
+
+	c.out = append(c.out, DanglingReference{
+		File:  filepath.ToSlash(filepath.Join(c.dir, file)),
+		Path:  pointer,
+		Kind:  kind,
+		Value: id,
+	})
+}
+
+func (c *checker) pointer(segments ...string) string {
+	var sb strings.Builder
+
+	sb.WriteString(c.prefix)
+
+	for _, s := range segments {
+		sb.WriteString("/")
+		sb.WriteString(escape(s))
+	}
+
+	return sb.String()
+}
+
+// ids returns keys of the collection and whether the collection is present
+func ids(collection any) (set, bool) {
+	var (
+		m, ok = collection.(map[string]any)
+		out   = set{}
+	)
+
+	for k, v := range m {
+		// null entries are deletions
+		if v != nil {
+			out[k] = struct{}{}
+		}
+	}
+
+	return out, ok
+}
+
+// themeIDs returns both keys and names of the themes, as themes may be keyed by name in the storage
+func themeIDs(collection any) (set, bool) {
+	var out, ok = ids(collection)
+
+	for _, theme := range utils.AsMap(collection) {
+		if name, isString := utils.AsMap(theme)["name"].(string); isString {
+			out[name] = struct{}{}
+		}
+	}
+
+	return out, ok
+}
+
+func asSlice(v any) []any {
+	if s, ok := v.([]any); ok {
+		return s
+	}
+
+	return nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	var out = make([]string, 0, len(m))
+
+	for k := range m {
+		out = append(out, k)
+	}
+
+	sort.Strings(out)
+
+	return out
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+func escape(segment string) string {
+	return pointerEscaper.Replace(segment)
+}
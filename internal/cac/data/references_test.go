@@ -0,0 +1,140 @@
+package data_test
+
+import (
+	"testing"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReferenceValidator(t *testing.T) {
+	server := func() map[string]any {
+		return map[string]any{
+			"policies": map[string]any{
+				"p1": map[string]any{"policy_name": "policy1"},
+			},
+			"scripts": map[string]any{
+				"s1": map[string]any{"name": "script1"},
+			},
+			"scopes_without_service": map[string]any{
+				"openid": map[string]any{},
+			},
+			"services": map[string]any{
+				"svc1": map[string]any{
+					"name":   "service1",
+					"scopes": map[string]any{"read": map[string]any{"policy_execution_points": map[string]any{"scope_client_assignment": "p1"}}},
+				},
+			},
+			"clients": map[string]any{
+				"c1": map[string]any{
+					"client_name":             "client1",
+					"scopes":                  []any{"openid", "read"},
+					"policy_execution_points": map[string]any{"client_access": "p1"},
+				},
+			},
+			"script_execution_points": map[string]any{
+				"post_authn_ctx": map[string]any{"idp1": map[string]any{"script_id": "s1"}},
+			},
+		}
+	}
+
+	t.Run("valid references", func(t *testing.T) {
+		patch := models.Rfc7396PatchOperation(server())
+		patch["id"] = "demo"
+
+		require.NoError(t, (&data.ReferenceValidator{}).Validate(&patch))
+	})
+
+	t.Run("dangling references", func(t *testing.T) {
+		patch := models.Rfc7396PatchOperation(server())
+		patch["id"] = "demo"
+		patch["clients"].(map[string]any)["c1"].(map[string]any)["scopes"] = []any{"openid", "write"}
+		patch["clients"].(map[string]any)["c1"].(map[string]any)["policy_execution_points"] = map[string]any{"client_access": "missing"}
+		patch["script_execution_points"] = map[string]any{
+			"post_authn_ctx": map[string]any{"idp1": map[string]any{"script_id": "deleted"}},
+			"token_minting":  map[string]any{"c1": map[string]any{"script_id": ""}},
+		}
+
+		err := (&data.ReferenceValidator{}).Validate(&patch)
+
+		var refErr *data.ReferenceError
+		require.ErrorAs(t, err, &refErr)
+		require.Equal(t, []data.DanglingReference{
+			{File: "workspaces/demo/clients/client1.yaml", Path: "/clients/c1/policy_execution_points/client_access", Kind: "policy", Value: "missing"},
+			{File: "workspaces/demo/clients/client1.yaml", Path: "/clients/c1/scopes/1", Kind: "scope", Value: "write"},
+			{File: "workspaces/demo/script_execution_points.yaml", Path: "/script_execution_points/post_authn_ctx/idp1/script_id", Kind: "script", Value: "deleted"},
+		}, refErr.References)
+	})
+
+	t.Run("system scopes of an exported workspace", func(t *testing.T) {
+		patch := models.Rfc7396PatchOperation{
+			"id":   "demo",
+			"name": "Demo",
+			"scopes_without_service": map[string]any{
+				"custom": map[string]any{"display_name": "Custom"},
+			},
+			"services": map[string]any{
+				"accounts": map[string]any{
+					"name": "Accounts API",
+					"type": "oauth2",
+					"scopes": map[string]any{
+						"accounts.read": map[string]any{"display_name": "Read accounts"},
+					},
+				},
+			},
+			"clients": map[string]any{
+				"demo": map[string]any{
+					"client_name":    "Demo Portal",
+					"system":         true,
+					"grant_types":    []any{"authorization_code", "refresh_token"},
+					"response_types": []any{"code"},
+					"scopes":         []any{"openid", "profile", "email", "address", "phone", "offline_access", "introspect_tokens", "revoke_tokens"},
+				},
+				"app": map[string]any{
+					"client_name": "Application",
+					"grant_types": []any{"client_credentials"},
+					"scopes":      []any{"accounts.read", "custom", "introspect_tokens", "accounts.write"},
+				},
+			},
+		}
+
+		err := (&data.ReferenceValidator{}).Validate(&patch)
+
+		var refErr *data.ReferenceError
+		require.ErrorAs(t, err, &refErr)
+		require.Equal(t, []data.DanglingReference{
+			{File: "workspaces/demo/clients/Application.yaml", Path: "/clients/app/scopes/3", Kind: "scope", Value: "accounts.write"},
+		}, refErr.References)
+	})
+
+	t.Run("references to missing collections are not checked", func(t *testing.T) {
+		patch := models.Rfc7396PatchOperation{
+			"clients": map[string]any{
+				"c1": map[string]any{"client_name": "client1", "scopes": []any{"write"}},
+			},
+		}
+
+		require.NoError(t, (&data.ReferenceValidator{}).Validate(&patch))
+	})
+
+	t.Run("tenant bindings", func(t *testing.T) {
+		s := server()
+		s["theme_binding"] = map[string]any{"theme_id": "missing"}
+		s["servers_bindings"] = map[string]any{"other": true, "missing": true}
+
+		patch := models.Rfc7396PatchOperation{
+			"themes":  map[string]any{"t1": map[string]any{"name": "theme1"}},
+			"servers": map[string]any{"demo": s, "other": map[string]any{}},
+		}
+
+		err := (&data.ReferenceValidator{Tenant: true}).Validate(&patch)
+
+		var refErr *data.ReferenceError
+		require.ErrorAs(t, err, &refErr)
+		require.Equal(t, []data.DanglingReference{
+			{File: "workspaces/demo/theme_binding.yaml", Path: "/servers/demo/theme_binding/theme_id", Kind: "theme", Value: "missing"},
+			{File: "workspaces/demo/servers_bindings.yaml", Path: "/servers/demo/servers_bindings/missing", Kind: "workspace", Value: "missing"},
+		}, refErr.References)
+	})
+}
@@ -0,0 +1,171 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/utils"
+	"github.com/dop251/goja/parser"
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/pkg/errors"
+)
+
+// SyntaxError is a syntax error of a script body or a rego policy definition
+type SyntaxError struct {
+	// Path is a JSON pointer (RFC 6901) of the script body or the policy definition
+	Path   string `json:"pointer"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Msg    string `json:"message"`
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.Path, e.Line, e.Column, e.Msg)
+}
+
+func (e *SyntaxError) Pointer() string {
+	return e.Path
+}
+
+func (e *SyntaxError) ContentPosition() (int, int) {
+	return e.Line, e.Column
+}
+
+// SyntaxErrors are all syntax errors found in the configuration
+type SyntaxErrors []error
+
+func (e SyntaxErrors) Error() string {
+	var msgs = make([]string, 0, len(e))
+
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+func (e SyntaxErrors) Unwrap() []error {
+	return e
+}
+
+// SyntaxValidator parses script bodies and rego policy definitions
+// scripts are parsed with an embedded JavaScript parser, rego policies are parsed and compiled with the embedded opa
+type SyntaxValidator struct {
+	Tenant bool
+}
+
+var _ ValidatorApi = &SyntaxValidator{}
+
+func (sv *SyntaxValidator) Validate(data *models.Rfc7396PatchOperation) error {
+	var (
+		normalized models.Rfc7396PatchOperation
+		servers    = map[string]map[string]any{}
+		errs       []error
+		err        error
+	)
+
+	if normalized, err = utils.NormalizePatch(*data); err != nil {
+		return err
+	}
+
+	if sv.Tenant {
+		for id, server := range utils.AsMap(normalized["servers"]) {
+			servers["/servers/"+escape(id)] = utils.AsMap(server)
+		}
+	} else {
+		servers[""] = normalized
+	}
+
+	for _, prefix := range sortedKeys(servers) {
+		server := servers[prefix]
+
+		for _, id := range sortedKeys(utils.AsMap(server["scripts"])) {
+			body, _ := utils.AsMap(utils.AsMap(server["scripts"])[id])["body"].(string)
+
+			if err = checkScript(prefix+"/scripts/"+escape(id)+"/body", body); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		for _, id := range sortedKeys(utils.AsMap(server["policies"])) {
+			policy := utils.AsMap(utils.AsMap(server["policies"])[id])
+
+			if language, _ := policy["language"].(string); language != "rego" {
+				continue
+			}
+
+			definition, _ := policy["definition"].(string)
+			errs = append(errs, checkPolicy(prefix+"/policies/"+escape(id)+"/definition", definition)...)
+		}
+	}
+
+	if len(errs) > 0 {
+		return SyntaxErrors(errs)
+	}
+
+	return nil
+}
+
+// checkScript returns the first syntax error of the script body, as the following ones are usually caused by it
+func checkScript(pointer string, body string) error {
+	var list parser.ErrorList
+
+	if body == "" {
+		return nil
+	}
+
+	_, err := parser.ParseFile(nil, "", body, 0)
+
+	if !errors.As(err, &list) || len(list) == 0 {
+		return err
+	}
+
+	return &SyntaxError{Path: pointer, Line: list[0].Position.Line, Column: list[0].Position.Column, Msg: list[0].Message}
+}
+
+// checkPolicy parses and compiles the rego policy definition, which reports parse errors and compile errors such as unsafe variables or undefined functions
+// the pointer is used as the module file name, so locations of errors are relative to the definition
+func checkPolicy(pointer string, definition string) []error {
+	var (
+		module   *ast.Module
+		compiler = ast.NewCompiler()
+		err      error
+	)
+
+	if definition == "" {
+		return nil
+	}
+
+	if module, err = ast.ParseModule(pointer, definition); err != nil {
+		return regoErrors(pointer, err)
+	}
+
+	if compiler.Compile(map[string]*ast.Module{pointer: module}); compiler.Failed() {
+		return regoErrors(pointer, compiler.Errors)
+	}
+
+	return nil
+}
+
+func regoErrors(pointer string, err error) []error {
+	var (
+		list ast.Errors
+		errs []error
+	)
+
+	if !errors.As(err, &list) {
+		return []error{errors.Wrapf(err, "failed to check rego policy %s", pointer)}
+	}
+
+	for _, e := range list {
+		if e.Location == nil {
+			errs = append(errs, errors.Errorf("%s: %s", pointer, e.Message))
+			continue
+		}
+
+		errs = append(errs, &SyntaxError{Path: pointer, Line: e.Location.Row, Column: e.Location.Col, Msg: e.Message})
+	}
+
+	return errs
+}
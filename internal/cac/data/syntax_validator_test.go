@@ -0,0 +1,117 @@
+package data_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/data"
+	"github.com/cloudentity/cac/internal/cac/provenance"
+	"github.com/stretchr/testify/require"
+)
+
+func syntaxErrors(err error) []data.SyntaxError {
+	var out []data.SyntaxError
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			out = append(out, syntaxErrors(e)...)
+		}
+
+		return out
+	}
+
+	var se *data.SyntaxError
+
+	if errors.As(err, &se) {
+		out = append(out, *se)
+	}
+
+	return out
+}
+
+func TestSyntaxValidator(t *testing.T) {
+	t.Run("scripts", func(t *testing.T) {
+		var (
+			v   = &data.SyntaxValidator{}
+			err = v.Validate(&models.Rfc7396PatchOperation{
+				"scripts": map[string]any{
+					"ok":  map[string]any{"body": "module.exports = async function(context) {\n  return {};\n};\n"},
+					"bad": map[string]any{"body": "module.exports = function(context) {\n  return 1 +;\n};\n"},
+				},
+			})
+		)
+
+		require.Equal(t, []data.SyntaxError{
+			{Path: "/scripts/bad/body", Line: 2, Column: 13, Msg: "Unexpected token ;"},
+		}, syntaxErrors(err))
+	})
+
+	t.Run("tenant", func(t *testing.T) {
+		var (
+			v   = &data.SyntaxValidator{Tenant: true}
+			err = v.Validate(&models.Rfc7396PatchOperation{
+				"servers": map[string]any{
+					"w": map[string]any{"scripts": map[string]any{"s1": map[string]any{"body": "function ("}}},
+				},
+			})
+		)
+
+		errs := syntaxErrors(err)
+		require.Len(t, errs, 1)
+		require.Equal(t, "/servers/w/scripts/s1/body", errs[0].Path)
+	})
+
+	t.Run("rego policies", func(t *testing.T) {
+		err := (&data.SyntaxValidator{}).Validate(&models.Rfc7396PatchOperation{
+			"policies": map[string]any{
+				"p1": map[string]any{"language": "rego", "definition": "package acp.authz\n\ndefault allow = false\n"},
+				"p2": map[string]any{"language": "rego", "definition": "package acp.authz\n\nallow {"},
+				"p3": map[string]any{"language": "cloudentity", "definition": "validators: []"},
+				"p4": map[string]any{"language": "rego", "definition": "package acp.authz\n\nallow {\n  x == 1\n}\n"},
+			},
+		})
+
+		require.Equal(t, []data.SyntaxError{
+			{Path: "/policies/p2/definition", Line: 3, Column: 7, Msg: "unexpected eof token"},
+			{Path: "/policies/p4/definition", Line: 4, Column: 3, Msg: "var x is unsafe"},
+		}, syntaxErrors(err))
+	})
+}
+
+func TestSyntaxErrorPosition(t *testing.T) {
+	var (
+		dir       = t.TempDir()
+		yaml      = filepath.Join(dir, "debug.yaml")
+		inline    = filepath.Join(dir, "inline.yaml")
+		policy    = filepath.Join(dir, "MFA.yaml")
+		positions = provenance.Map{
+			"/scripts/s1/body":        {File: yaml, Line: 3, Column: 1},
+			"/scripts/s2/body":        {File: inline, Line: 2, Column: 1},
+			"/policies/p1/definition": {File: policy, Line: 4, Column: 1},
+		}
+	)
+
+	require.NoError(t, os.WriteFile(yaml, []byte("id: s1\nname: debug\nbody: {{ include \"debug.js\" | nindent 2 }}\n"), 0600))
+	require.NoError(t, os.WriteFile(inline, []byte("id: s2\nbody: |-\n  function (\n"), 0600))
+	require.NoError(t, os.WriteFile(policy, []byte("id: p1\npolicy_name: MFA\nlanguage: rego\ndefinition: {{ include \"MFA.rego\" | nindent 2 }}\n"), 0600))
+
+	position, ok := positions.PositionOf(&data.SyntaxError{Path: "/scripts/s1/body", Line: 2, Column: 13})
+	require.True(t, ok)
+	require.Equal(t, provenance.Position{File: filepath.Join(dir, "debug.js"), Line: 2, Column: 13}, position)
+
+	position, ok = positions.PositionOf(&data.SyntaxError{Path: "/scripts/s2/body", Line: 1, Column: 10})
+	require.True(t, ok)
+	require.Equal(t, provenance.Position{File: inline, Line: 3, Column: 10}, position)
+
+	errs := syntaxErrors((&data.SyntaxValidator{}).Validate(&models.Rfc7396PatchOperation{
+		"policies": map[string]any{"p1": map[string]any{"language": "rego", "definition": "package acp.authz\n\nallow {"}},
+	}))
+	require.Len(t, errs, 1)
+
+	position, ok = positions.PositionOf(&errs[0])
+	require.True(t, ok)
+	require.Equal(t, provenance.Position{File: filepath.Join(dir, "MFA.rego"), Line: 3, Column: 7}, position)
+}
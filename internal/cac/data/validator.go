@@ -1,7 +1,28 @@
 package data
 
-import "github.com/cloudentity/acp-client-go/clients/hub/models"
+import (
+	"errors"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+)
 
 type ValidatorApi interface {
 	Validate(data *models.Rfc7396PatchOperation) error
 }
+
+// Validators runs all validators and returns their errors joined
+type Validators []ValidatorApi
+
+var _ ValidatorApi = Validators{}
+
+func (vs Validators) Validate(data *models.Rfc7396PatchOperation) error {
+	var errs []error
+
+	for _, v := range vs {
+		if err := v.Validate(data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,211 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+)
+
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+// Change describes a single difference between target and source
+// Path is a JSON pointer (RFC 6901), Old is the target value and New is the source value
+type Change struct {
+	Path string     `json:"path"`
+	Kind ChangeKind `json:"kind"`
+	Old  any        `json:"old,omitzero"`
+	New  any        `json:"new,omitzero"`
+}
+
+// Operation is a single RFC 6902 JSON Patch operation
+type Operation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value,omitzero"`
+}
+
+// rootPath mimics cmp.Path GoString format, so secret and volatile field expressions can be matched
+const rootPath = "{models.Rfc7396PatchOperation}"
+
+// Changes returns a sorted list of changes required to turn target into source
+func Changes(source models.Rfc7396PatchOperation, target models.Rfc7396PatchOperation, opts ...Option) ([]Change, error) {
+	var (
+		options = &Options{}
+		changes = []Change{}
+		err     error
+	)
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if source, target, err = prepare(source, target, options); err != nil {
+		return nil, err
+	}
+
+	collectChanges(source, target, "", rootPath, options, &changes)
+
+	return changes, nil
+}
+
+// JSONPatch returns RFC 6902 operations which applied to the target produce the source
+func JSONPatch(source models.Rfc7396PatchOperation, target models.Rfc7396PatchOperation, opts ...Option) ([]Operation, error) {
+	var (
+		changes []Change
+		ops     = []Operation{}
+		err     error
+	)
+
+	if changes, err = Changes(source, target, opts...); err != nil {
+		return nil, err
+	}
+
+	for _, c := range changes {
+		switch c.Kind {
+		case ChangeAdded:
+			ops = append(ops, Operation{Op: "add", Path: c.Path, Value: c.New})
+		case ChangeRemoved:
+			ops = append(ops, Operation{Op: "remove", Path: c.Path})
+		case ChangeModified:
+			ops = append(ops, Operation{Op: "replace", Path: c.Path, Value: c.New})
+		}
+	}
+
+	return ops, nil
+}
+
+func collectChanges(source map[string]any, target map[string]any, pointer string, path string, options *Options, changes *[]Change) {
+	for _, k := range sortedKeys(source, target) {
+		var (
+			ptr     = pointer + "/" + escapePointer(k)
+			p       = mapIndexPath(path, k)
+			sv, sok = source[k]
+			tv, tok = target[k]
+		)
+
+		if options.ignored(p) {
+			continue
+		}
+
+		switch {
+		case sok && !tok:
+			*changes = append(*changes, Change{Path: ptr, Kind: ChangeAdded, New: sv})
+		case !sok && tok:
+			if options.keep(p) {
+				continue
+			}
+
+			*changes = append(*changes, Change{Path: ptr, Kind: ChangeRemoved, Old: tv})
+		default:
+			sm, smok := sv.(map[string]any)
+			tm, tmok := tv.(map[string]any)
+
+			if smok && tmok {
+				collectChanges(sm, tm, ptr, p, options, changes)
+				continue
+			}
+
+			if !reflect.DeepEqual(sv, tv) {
+				*changes = append(*changes, Change{Path: ptr, Kind: ChangeModified, Old: tv, New: sv})
+			}
+		}
+	}
+}
+
+// CountByCollection returns the number of differing resources per top level collection
+// top level fields which are not collections are counted as a single resource
+func CountByCollection(changes []Change) map[string]int {
+	var (
+		resources = map[string]map[string]struct{}{}
+		out       = map[string]int{}
+	)
+
+	for _, c := range changes {
+		var (
+			segments   = strings.SplitN(strings.TrimPrefix(c.Path, "/"), "/", 3)
+			collection = unescapePointer(segments[0])
+		)
+
+		if resources[collection] == nil {
+			resources[collection] = map[string]struct{}{}
+		}
+
+		if len(segments) > 1 {
+			resources[collection][segments[1]] = struct{}{}
+			continue
+		}
+
+		// whole collection was added or removed
+		value, ok := c.New.(map[string]any)
+
+		if c.Kind == ChangeRemoved {
+			value, ok = c.Old.(map[string]any)
+		}
+
+		if !ok {
+			resources[collection][""] = struct{}{}
+			continue
+		}
+
+		for id := range value {
+			resources[collection][id] = struct{}{}
+		}
+	}
+
+	for collection, ids := range resources {
+		out[collection] = len(ids)
+	}
+
+	return out
+}
+
+// FormatCounts formats the result of CountByCollection as a single line
+func FormatCounts(counts map[string]int) string {
+	var (
+		total       int
+		collections = make([]string, 0, len(counts))
+		parts       = make([]string, 0, len(counts))
+	)
+
+	if len(counts) == 0 {
+		return "no differences"
+	}
+
+	for collection, count := range counts {
+		collections = append(collections, collection)
+		total += count
+	}
+
+	sort.Strings(collections)
+
+	for _, collection := range collections {
+		parts = append(parts, fmt.Sprintf("%s: %d", collection, counts[collection]))
+	}
+
+	return fmt.Sprintf("%d resources differ (%s)", total, strings.Join(parts, ", "))
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+func escapePointer(key string) string {
+	return pointerEscaper.Replace(key)
+}
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+func unescapePointer(segment string) string {
+	return pointerUnescaper.Replace(segment)
+}
+
+func mapIndexPath(path string, key string) string {
+	return fmt.Sprintf("%s[%q]", path, key)
+}
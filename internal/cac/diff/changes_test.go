@@ -0,0 +1,111 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/diff"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChanges(t *testing.T) {
+	source := func() models.Rfc7396PatchOperation {
+		return models.Rfc7396PatchOperation{
+			"clients": map[string]any{
+				"c1": map[string]any{"client_name": "client1", "updated_at": "2024-02-01"},
+				"c3": map[string]any{"client_name": "client3"},
+			},
+			"webhooks": map[string]any{
+				"w1": map[string]any{"api_key": "new"},
+			},
+		}
+	}
+	target := func() models.Rfc7396PatchOperation {
+		return models.Rfc7396PatchOperation{
+			"clients": map[string]any{
+				"c1":  map[string]any{"client_name": "old", "updated_at": "2024-01-01"},
+				"c/2": map[string]any{"client_name": "client2"},
+			},
+			"webhooks": map[string]any{
+				"w1": map[string]any{"api_key": "old"},
+			},
+		}
+	}
+
+	t.Run("list changes", func(t *testing.T) {
+		changes, err := diff.Changes(source(), target(), diff.FilterVolatileFields(true), diff.FilterSecretFields(true))
+		require.NoError(t, err)
+		require.Equal(t, []diff.Change{
+			{Path: "/clients/c~12", Kind: diff.ChangeRemoved, Old: map[string]any{"client_name": "client2"}},
+			{Path: "/clients/c1/client_name", Kind: diff.ChangeModified, Old: "old", New: "client1"},
+			{Path: "/clients/c3", Kind: diff.ChangeAdded, New: map[string]any{"client_name": "client3"}},
+		}, changes)
+	})
+
+	t.Run("list changes with secrets and volatile fields", func(t *testing.T) {
+		changes, err := diff.Changes(source(), target())
+		require.NoError(t, err)
+		require.Len(t, changes, 5)
+		require.Equal(t, "/webhooks/w1/api_key", changes[4].Path)
+	})
+
+	t.Run("list changes only present at source", func(t *testing.T) {
+		changes, err := diff.Changes(source(), target(), diff.OnlyPresent(true), diff.FilterVolatileFields(true), diff.FilterSecretFields(true))
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+	})
+
+	t.Run("json patch", func(t *testing.T) {
+		ops, err := diff.JSONPatch(source(), target(), diff.FilterVolatileFields(true), diff.FilterSecretFields(true))
+		require.NoError(t, err)
+		require.Equal(t, []diff.Operation{
+			{Op: "remove", Path: "/clients/c~12"},
+			{Op: "replace", Path: "/clients/c1/client_name", Value: "client1"},
+			{Op: "add", Path: "/clients/c3", Value: map[string]any{"client_name": "client3"}},
+		}, ops)
+	})
+
+	t.Run("render merge patch", func(t *testing.T) {
+		out, err := diff.Render(source(), target(), diff.FilterVolatileFields(true), diff.FilterSecretFields(true), diff.Output(diff.OutputMergePatch))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"clients": {"c1": {"client_name": "client1"}, "c3": {"client_name": "client3"}, "c/2": null}}`, out)
+	})
+
+	t.Run("render merge patch with secrets by default", func(t *testing.T) {
+		out, err := diff.Render(source(), target(), diff.FilterVolatileFields(true), diff.Output(diff.OutputMergePatch))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"clients": {"c1": {"client_name": "client1"}, "c3": {"client_name": "client3"}, "c/2": null}, "webhooks": {"w1": {"api_key": "new"}}}`, out)
+	})
+
+	t.Run("render yaml", func(t *testing.T) {
+		out, err := diff.Render(source(), target(), diff.FilterVolatileFields(true), diff.FilterSecretFields(true), diff.Output(diff.OutputYAML))
+		require.NoError(t, err)
+		require.Equal(t, `- path: /clients/c~12
+  kind: removed
+  old:
+    client_name: client2
+- path: /clients/c1/client_name
+  kind: modified
+  old: old
+  new: client1
+- path: /clients/c3
+  kind: added
+  new:
+    client_name: client3
+`, out)
+	})
+}
+
+func TestCountByCollection(t *testing.T) {
+	counts := diff.CountByCollection([]diff.Change{
+		{Path: "/clients/c1/client_name", Kind: diff.ChangeModified},
+		{Path: "/clients/c1/scopes", Kind: diff.ChangeModified},
+		{Path: "/clients/c2", Kind: diff.ChangeAdded},
+		{Path: "/policies", Kind: diff.ChangeRemoved, Old: map[string]any{"p1": map[string]any{}, "p2": map[string]any{}}},
+		{Path: "/name", Kind: diff.ChangeModified},
+	})
+
+	require.Equal(t, map[string]int{"clients": 2, "policies": 2, "name": 1}, counts)
+	require.Equal(t, "5 resources differ (clients: 2, name: 1, policies: 2)", diff.FormatCounts(counts))
+	require.Equal(t, "no differences", diff.FormatCounts(map[string]int{}))
+}
@@ -5,9 +5,14 @@ import (
 	"github.com/cloudentity/acp-client-go/clients/hub/models"
 	"github.com/cloudentity/cac/internal/cac/api"
 	"github.com/cloudentity/cac/internal/cac/utils"
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+	ccyaml "github.com/goccy/go-yaml"
 	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
 	"golang.org/x/exp/slog"
 	"regexp"
+	"strings"
 )
 
 type Options struct {
@@ -15,9 +20,33 @@ type Options struct {
 	PresentAtSource bool
 	Filters         []string
 	Secrets         bool
+	FilterSecrets   bool
+	MissingSecrets  bool
 	FilterVolatile  bool
+	Output          OutputFormat
+	Format          TextFormat
+	layout          *layout
 }
 
+type OutputFormat string
+
+const (
+	OutputText       OutputFormat = "text"
+	OutputJSON       OutputFormat = "json"
+	OutputYAML       OutputFormat = "yaml"
+	OutputJSONPatch  OutputFormat = "jsonpatch"
+	OutputMergePatch OutputFormat = "merge-patch"
+)
+
+// TextFormat controls how the text output is rendered
+type TextFormat string
+
+const (
+	FormatTree    TextFormat = "tree"
+	FormatSummary TextFormat = "summary"
+	FormatFiles   TextFormat = "files"
+)
+
 type Option func(*Options)
 
 func Colorize(colors bool) Option {
@@ -44,12 +73,42 @@ func WithSecrets(secrets bool) Option {
 	}
 }
 
+// FilterSecretFields drops secret fields from changes and patches
+// the text tree output filters secrets unless WithSecrets is set, other outputs keep them unless this option is set
+func FilterSecretFields(filterSecrets bool) Option {
+	return func(options *Options) {
+		options.FilterSecrets = filterSecrets
+	}
+}
+
+// IgnoreMissingSecrets does not remove secret fields missing at source from the target
+// so configuration stored without secrets can be compared with configuration read with secrets
+func IgnoreMissingSecrets(ignore bool) Option {
+	return func(options *Options) {
+		options.MissingSecrets = ignore
+	}
+}
+
 func FilterVolatileFields(filterVolatile bool) Option {
 	return func(options *Options) {
 		options.FilterVolatile = filterVolatile
 	}
 }
 
+func Output(output OutputFormat) Option {
+	return func(options *Options) {
+		options.Output = output
+	}
+}
+
+func Format(format TextFormat) Option {
+	return func(options *Options) {
+		options.Format = format
+	}
+}
+
+// secretFields are expressions of secret fields matched against paths in the cmp.Path GoString format
+// they are ignored in comparisons unless secrets are requested
 var secretFields = []string{
 	"rotated_secrets",
 	"hashed_rotated_secret",
@@ -59,6 +118,15 @@ var secretFields = []string{
 	"mfa_methods.*auth",
 }
 
+// credentialFields are secret values returned only when secrets are requested, which are compared like other fields,
+// but are encrypted or stored with a secret provider by pull together with secretFields
+var credentialFields = []string{
+	"\\{models.Rfc7396PatchOperation\\}\\[\\\"secret\\\"\\]", // workspace secret (when comparing workspace config)
+	"servers.*\\[\\\"secret\\\"\\]",                          // workspace secret (when comparing tenant config)
+	"clients.*client_secret",
+	"idps.*credentials",
+}
+
 var volatileFields = []string{
 	"updated_at",
 	"last_active",
@@ -66,28 +134,76 @@ var volatileFields = []string{
 
 var fieldsFilter = func(fields []string) cmp.Option {
 	return cmp.FilterPath(func(p cmp.Path) bool {
-		for _, vf := range fields {
-			result, err := regexp.MatchString(vf, p.GoString())
+		return matchFields(fields, p.GoString())
+	}, cmp.Ignore())
+}
 
-			if err != nil {
-				slog.Error("failed to match field", "field", vf, "error", err)
-				return false
-			}
+func matchFields(fields []string, path string) bool {
+	for _, vf := range fields {
+		result, err := regexp.MatchString(vf, path)
 
-			if result {
-				return true
-			}
+		if err != nil {
+			slog.Error("failed to match field", "field", vf, "error", err)
+			return false
+		}
 
-			continue
+		if result {
+			return true
 		}
-		return false
-	}, cmp.Ignore())
+	}
+
+	return false
+}
+
+// ignored returns true if the field at the given path is excluded from comparison by options
+// path is expected in the cmp.Path GoString format, so the same field expressions can be used
+func (o *Options) ignored(path string) bool {
+	if o.FilterVolatile && matchFields(volatileFields, path) {
+		return true
+	}
+
+	return o.FilterSecrets && matchFields(secretFields, path)
+}
+
+// keep returns true if the field at the given path, which is missing at source, is not removed from the target
+func (o *Options) keep(path string) bool {
+	return o.ignored(path) || o.MissingSecrets && isSecret(path)
+}
+
+// IsSecret returns true if the value under the JSON pointer is a secret or credential field
+func IsSecret(pointer string) bool {
+	var path = rootPath
+
+	for _, segment := range strings.Split(pointer, "/")[1:] {
+		path = mapIndexPath(path, unescapePointer(segment))
+	}
+
+	return isSecret(path)
+}
+
+func isSecret(path string) bool {
+	return matchFields(secretFields, path) || matchFields(credentialFields, path)
 }
 
 var filerVolatileFields = fieldsFilter(volatileFields)
 var filterSecretFields = fieldsFilter(secretFields)
 
 func Diff(ctx context.Context, source api.Source, target api.Source, workspace string, opts ...Option) (string, error) {
+	var (
+		server1 models.Rfc7396PatchOperation
+		server2 models.Rfc7396PatchOperation
+		err     error
+	)
+
+	if server1, server2, err = Read(ctx, source, target, workspace, opts...); err != nil {
+		return "", err
+	}
+
+	return Render(server1, server2, opts...)
+}
+
+// Read reads configurations to compare from source and target
+func Read(ctx context.Context, source api.Source, target api.Source, workspace string, opts ...Option) (models.Rfc7396PatchOperation, models.Rfc7396PatchOperation, error) {
 	var (
 		server1  models.Rfc7396PatchOperation
 		server2  models.Rfc7396PatchOperation
@@ -113,37 +229,125 @@ func Diff(ctx context.Context, source api.Source, target api.Source, workspace s
 	}
 
 	if server1, err = source.Read(ctx, readOpts...); err != nil {
-		return "", err
+		return nil, nil, err
 	}
 
 	if server2, err = target.Read(ctx, readOpts...); err != nil {
-		return "", err
+		return nil, nil, err
 	}
 
-	return Tree(server1, server2, opts...)
+	return server1, server2, nil
 }
 
-func Tree(source models.Rfc7396PatchOperation, target models.Rfc7396PatchOperation, opts ...Option) (string, error) {
-	var (
-		options  = &Options{}
-		diffOpts = cmp.Options{}
-		err      error
-	)
+// Render compares source with target and renders the result in the requested output format
+func Render(source models.Rfc7396PatchOperation, target models.Rfc7396PatchOperation, opts ...Option) (string, error) {
+	var options = &Options{}
 
 	for _, opt := range opts {
 		opt(options)
 	}
 
+	switch options.Output {
+	case "", OutputText:
+		return renderText(source, target, options, opts...)
+	case OutputJSON:
+		return render(Changes(source, target, opts...))
+	case OutputYAML:
+		return renderYAML(render(Changes(source, target, opts...)))
+	case OutputJSONPatch:
+		return render(JSONPatch(source, target, opts...))
+	case OutputMergePatch:
+		return render(Patch(source, target, opts...))
+	}
+
+	return "", errors.Errorf("unknown output format: %s", options.Output)
+}
+
+func renderText(source models.Rfc7396PatchOperation, target models.Rfc7396PatchOperation, options *Options, opts ...Option) (string, error) {
+	var (
+		summaries []CollectionSummary
+		out       string
+		err       error
+	)
+
+	switch options.Format {
+	case "", FormatTree:
+		return Tree(source, target, opts...)
+	case FormatSummary:
+		if summaries, err = Summary(source, target, opts...); err != nil {
+			return "", err
+		}
+
+		if options.Color {
+			return colorize(RenderSummary(summaries)), nil
+		}
+
+		return RenderSummary(summaries), nil
+	case FormatFiles:
+		if out, err = Files(source, target, opts...); err != nil {
+			return "", err
+		}
+
+		if options.Color {
+			return colorize(out), nil
+		}
+
+		return out, nil
+	}
+
+	return "", errors.Errorf("unknown format: %s", options.Format)
+}
+
+func render[T any](it T, err error) (string, error) {
+	var bts []byte
+
+	if err != nil {
+		return "", err
+	}
+
+	if bts, err = json.Marshal(it, json.Deterministic(true), json.FormatNilSliceAsNull(false), jsontext.WithIndent("  ")); err != nil {
+		return "", errors.Wrap(err, "failed to marshal diff")
+	}
+
+	return string(bts) + "\n", nil
+}
+
+func renderYAML(out string, err error) (string, error) {
+	var bts []byte
+
+	if err != nil {
+		return "", err
+	}
+
+	if bts, err = ccyaml.JSONToYAML([]byte(out)); err != nil {
+		return "", errors.Wrap(err, "failed to convert diff to yaml")
+	}
+
+	return string(bts), nil
+}
+
+// prepare normalizes both configurations so they can be compared
+func prepare(source models.Rfc7396PatchOperation, target models.Rfc7396PatchOperation, options *Options) (models.Rfc7396PatchOperation, models.Rfc7396PatchOperation, error) {
+	var err error
+
 	utils.CleanPatch(source)
 	utils.CleanPatch(target)
 
 	// marshaling structs to json and back to get proper field names in the comparison
+	if source, err = utils.FilterPatch(source, options.Filters); err != nil {
+		return nil, nil, err
+	}
+
+	if target, err = utils.FilterPatch(target, options.Filters); err != nil {
+		return nil, nil, err
+	}
+
 	if source, err = utils.NormalizePatch(source); err != nil {
-		return "", err
+		return nil, nil, err
 	}
 
 	if target, err = utils.NormalizePatch(target); err != nil {
-		return "", err
+		return nil, nil, err
 	}
 
 	if options.PresentAtSource {
@@ -154,6 +358,24 @@ func Tree(source models.Rfc7396PatchOperation, target models.Rfc7396PatchOperati
 		}
 	}
 
+	return source, target, nil
+}
+
+func Tree(source models.Rfc7396PatchOperation, target models.Rfc7396PatchOperation, opts ...Option) (string, error) {
+	var (
+		options  = &Options{}
+		diffOpts = cmp.Options{}
+		err      error
+	)
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if source, target, err = prepare(source, target, options); err != nil {
+		return "", err
+	}
+
 	if options.FilterVolatile {
 		diffOpts = append(diffOpts, filerVolatileFields)
 	}
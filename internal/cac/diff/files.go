@@ -0,0 +1,209 @@
+package diff
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/cloudentity/cac/internal/cac/storage"
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+type layout struct {
+	constructor storage.Constructor
+	workspace   string
+}
+
+// StorageLayout configures storage used to render configurations to files for the files format
+func StorageLayout(constr storage.Constructor, workspace string) Option {
+	return func(options *Options) {
+		options.layout = &layout{
+			constructor: constr,
+			workspace:   workspace,
+		}
+	}
+}
+
+// Files renders both configurations to temporary directories using storage layout
+// and returns unified diffs of every file which differs, target is treated as the original version
+func Files(source models.Rfc7396PatchOperation, target models.Rfc7396PatchOperation, opts ...Option) (string, error) {
+	var (
+		options   = &Options{}
+		sourceDir string
+		targetDir string
+		err       error
+	)
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.layout == nil {
+		return "", errors.New("storage layout is required to compare files")
+	}
+
+	if source, target, err = prepare(source, target, options); err != nil {
+		return "", err
+	}
+
+	stripIgnored(source, rootPath, options)
+	stripIgnored(target, rootPath, options)
+
+	if sourceDir, err = writeTemp(source, options.layout); err != nil {
+		return "", errors.Wrap(err, "failed to render source")
+	}
+
+	defer os.RemoveAll(sourceDir)
+
+	if targetDir, err = writeTemp(target, options.layout); err != nil {
+		return "", errors.Wrap(err, "failed to render target")
+	}
+
+	defer os.RemoveAll(targetDir)
+
+	return diffDirs(sourceDir, targetDir)
+}
+
+func writeTemp(data models.Rfc7396PatchOperation, l *layout) (string, error) {
+	var (
+		dir string
+		err error
+	)
+
+	if dir, err = os.MkdirTemp("", "cac-diff-"); err != nil {
+		return "", err
+	}
+
+	if err = l.constructor(&storage.Configuration{DirPath: dir}).Write(context.Background(), data, api.WithWorkspace(l.workspace)); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	return dir, nil
+}
+
+func diffDirs(sourceDir string, targetDir string) (string, error) {
+	var (
+		sb    strings.Builder
+		files []string
+		err   error
+	)
+
+	if files, err = listFiles(sourceDir, targetDir); err != nil {
+		return "", err
+	}
+
+	for _, file := range files {
+		var (
+			ud = difflib.UnifiedDiff{
+				FromFile: filepath.ToSlash(filepath.Join("a", file)),
+				ToFile:   filepath.ToSlash(filepath.Join("b", file)),
+				Context:  3,
+			}
+			unified string
+		)
+
+		if ud.B, err = readLines(filepath.Join(sourceDir, file)); err != nil {
+			return "", err
+		}
+
+		if ud.A, err = readLines(filepath.Join(targetDir, file)); err != nil {
+			return "", err
+		}
+
+		if ud.A == nil {
+			ud.FromFile = "/dev/null"
+		}
+
+		if ud.B == nil {
+			ud.ToFile = "/dev/null"
+		}
+
+		if unified, err = difflib.GetUnifiedDiffString(ud); err != nil {
+			return "", errors.Wrapf(err, "failed to compare %s", file)
+		}
+
+		sb.WriteString(unified)
+	}
+
+	return sb.String(), nil
+}
+
+// listFiles returns sorted relative paths of files present in any of the directories
+func listFiles(dirs ...string) ([]string, error) {
+	var (
+		seen = map[string]struct{}{}
+		out  []string
+	)
+
+	for _, dir := range dirs {
+		if err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			rel, err := filepath.Rel(dir, path)
+
+			if err != nil {
+				return err
+			}
+
+			seen[rel] = struct{}{}
+
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	for f := range seen {
+		out = append(out, f)
+	}
+
+	sort.Strings(out)
+
+	return out, nil
+}
+
+// readLines returns lines of the file or nil if the file does not exist
+func readLines(path string) ([]string, error) {
+	bts, err := os.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.SplitAfter(string(bts), "\n")
+
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines, nil
+}
+
+// stripIgnored removes fields excluded from comparison so they are not rendered to files
+func stripIgnored(data map[string]any, path string, options *Options) {
+	for k, v := range data {
+		p := mapIndexPath(path, k)
+
+		if options.ignored(p) {
+			delete(data, k)
+			continue
+		}
+
+		if m, ok := v.(map[string]any); ok {
+			stripIgnored(m, p, options)
+		}
+	}
+}
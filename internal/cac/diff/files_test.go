@@ -0,0 +1,41 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/diff"
+	"github.com/cloudentity/cac/internal/cac/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFiles(t *testing.T) {
+	source := models.Rfc7396PatchOperation{
+		"scripts": map[string]any{
+			"s1": map[string]any{"name": "debug", "body": "line1\nline2 changed\nline3\n"},
+		},
+	}
+	target := models.Rfc7396PatchOperation{
+		"scripts": map[string]any{
+			"s1": map[string]any{"name": "debug", "body": "line1\nline2\nline3\n"},
+		},
+	}
+
+	t.Run("compare extracted script line by line", func(t *testing.T) {
+		out, err := diff.Files(source, target, diff.StorageLayout(storage.InitServerStorage, "demo"))
+		require.NoError(t, err)
+		require.Equal(t, `--- a/workspaces/demo/scripts/debug.js
++++ b/workspaces/demo/scripts/debug.js
+@@ -1,3 +1,3 @@
+ line1
+-line2
++line2 changed
+ line3
+`, out)
+	})
+
+	t.Run("storage layout is required", func(t *testing.T) {
+		_, err := diff.Files(source, target)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,100 @@
+package diff
+
+import (
+	"reflect"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/utils"
+)
+
+// Patch computes RFC 7396 merge patch which applied to the target produces the source
+// When OnlyPresent option is enabled, keys missing at source are not removed from the target
+func Patch(source models.Rfc7396PatchOperation, target models.Rfc7396PatchOperation, opts ...Option) (models.Rfc7396PatchOperation, error) {
+	var (
+		options = &Options{}
+		err     error
+	)
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if source, target, err = prepare(source, target, options); err != nil {
+		return nil, err
+	}
+
+	return mergePatch(source, target, rootPath, options), nil
+}
+
+// ChangedCollections returns collections of the source which differ from the target
+// The source is left intact, so returned collections can be sent as they are
+func ChangedCollections(source models.Rfc7396PatchOperation, target models.Rfc7396PatchOperation, opts ...Option) (models.Rfc7396PatchOperation, error) {
+	var (
+		normalized models.Rfc7396PatchOperation
+		patch      models.Rfc7396PatchOperation
+		changed    = models.Rfc7396PatchOperation{}
+		err        error
+	)
+
+	// comparison cleans the configuration in place, so a copy is used
+	if normalized, err = utils.NormalizePatch(source); err != nil {
+		return nil, err
+	}
+
+	if patch, err = Patch(normalized, target, opts...); err != nil {
+		return nil, err
+	}
+
+	for k := range patch {
+		changed[k] = source[k]
+	}
+
+	return changed, nil
+}
+
+func mergePatch(source map[string]any, target map[string]any, path string, options *Options) map[string]any {
+	var out = map[string]any{}
+
+	for k, sv := range source {
+		var (
+			p      = mapIndexPath(path, k)
+			tv, ok = target[k]
+		)
+
+		if options.ignored(p) {
+			continue
+		}
+
+		if !ok {
+			out[k] = sv
+			continue
+		}
+
+		sm, sok := sv.(map[string]any)
+		tm, tok := tv.(map[string]any)
+
+		if sok && tok {
+			if nested := mergePatch(sm, tm, p, options); len(nested) > 0 {
+				out[k] = nested
+			}
+
+			continue
+		}
+
+		if !reflect.DeepEqual(sv, tv) {
+			out[k] = sv
+		}
+	}
+
+	if options.PresentAtSource {
+		return out
+	}
+
+	for k := range target {
+		if _, ok := source[k]; !ok && !options.keep(mapIndexPath(path, k)) {
+			out[k] = nil
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,127 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/diff"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatch(t *testing.T) {
+	tcs := []struct {
+		name     string
+		source   models.Rfc7396PatchOperation
+		target   models.Rfc7396PatchOperation
+		opts     []diff.Option
+		expected models.Rfc7396PatchOperation
+	}{
+		{
+			name:     "no changes",
+			source:   models.Rfc7396PatchOperation{"a": map[string]any{"b": 1}},
+			target:   models.Rfc7396PatchOperation{"a": map[string]any{"b": 1}},
+			expected: models.Rfc7396PatchOperation{},
+		},
+		{
+			name:     "modified nested field",
+			source:   models.Rfc7396PatchOperation{"a": map[string]any{"b": 1, "c": "x"}},
+			target:   models.Rfc7396PatchOperation{"a": map[string]any{"b": 2, "c": "x"}},
+			expected: models.Rfc7396PatchOperation{"a": map[string]any{"b": float64(1)}},
+		},
+		{
+			name:     "lists are replaced",
+			source:   models.Rfc7396PatchOperation{"a": []any{"x", "y"}},
+			target:   models.Rfc7396PatchOperation{"a": []any{"x"}},
+			expected: models.Rfc7396PatchOperation{"a": []any{"x", "y"}},
+		},
+		{
+			name:     "missing keys are removed",
+			source:   models.Rfc7396PatchOperation{"a": map[string]any{"b": 1}},
+			target:   models.Rfc7396PatchOperation{"a": map[string]any{"b": 1, "c": 2}, "d": "x"},
+			expected: models.Rfc7396PatchOperation{"a": map[string]any{"c": nil}, "d": nil},
+		},
+		{
+			name:     "missing keys are kept when only present",
+			source:   models.Rfc7396PatchOperation{"a": map[string]any{"b": 1}},
+			target:   models.Rfc7396PatchOperation{"a": map[string]any{"b": 1, "c": 2}, "d": "x"},
+			opts:     []diff.Option{diff.OnlyPresent(true)},
+			expected: models.Rfc7396PatchOperation{},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := diff.Patch(tc.source, tc.target, tc.opts...)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestChangedCollections(t *testing.T) {
+	var (
+		local = func() models.Rfc7396PatchOperation {
+			return models.Rfc7396PatchOperation{
+				"clients":  map[string]any{"c1": map[string]any{"client_name": "app"}},
+				"scopes":   map[string]any{"s1": map[string]any{"name": "openid"}},
+				"webhooks": map[string]any{"w1": map[string]any{"url": "https://example.com"}},
+			}
+		}
+		// remote configuration of an import, read with secrets and volatile fields which are not stored locally
+		remote = func() models.Rfc7396PatchOperation {
+			return models.Rfc7396PatchOperation{
+				"clients": map[string]any{"c1": map[string]any{
+					"client_name":     "app",
+					"client_secret":   "secret",
+					"rotated_secrets": []any{"old"},
+					"updated_at":      "2024-01-01T00:00:00Z",
+				}},
+				"scopes":   map[string]any{"s1": map[string]any{"name": "openid"}},
+				"webhooks": map[string]any{"w1": map[string]any{"url": "https://example.com", "api_key": "key"}},
+				"jwks":     map[string]any{"keys": []any{}},
+			}
+		}
+		opts = []diff.Option{
+			diff.FilterVolatileFields(true),
+			diff.IgnoreMissingSecrets(true),
+		}
+	)
+
+	t.Run("local equals remote so nothing is pushed", func(t *testing.T) {
+		changed, err := diff.ChangedCollections(local(), remote(), opts...)
+		require.NoError(t, err)
+		require.Empty(t, changed)
+	})
+
+	t.Run("secrets stored locally are compared", func(t *testing.T) {
+		source := local()
+		source["clients"].(map[string]any)["c1"].(map[string]any)["client_secret"] = "rotated"
+		source["webhooks"].(map[string]any)["w1"].(map[string]any)["api_key"] = "key"
+
+		changed, err := diff.ChangedCollections(source, remote(), opts...)
+		require.NoError(t, err)
+		require.Equal(t, models.Rfc7396PatchOperation{"clients": source["clients"]}, changed)
+	})
+
+	t.Run("only filtered collections are compared", func(t *testing.T) {
+		source := local()
+		source["clients"].(map[string]any)["c1"].(map[string]any)["client_name"] = "changed"
+
+		changed, err := diff.ChangedCollections(source, remote(), append(opts, diff.Filters("scopes"))...)
+		require.NoError(t, err)
+		require.Empty(t, changed)
+
+		changed, err = diff.ChangedCollections(source, remote(), opts...)
+		require.NoError(t, err)
+		require.Equal(t, models.Rfc7396PatchOperation{"clients": source["clients"]}, changed)
+	})
+
+	t.Run("collections missing locally are changed", func(t *testing.T) {
+		target := remote()
+		target["policies"] = map[string]any{"p1": map[string]any{"policy_name": "deny"}}
+
+		changed, err := diff.ChangedCollections(local(), target, opts...)
+		require.NoError(t, err)
+		require.Equal(t, models.Rfc7396PatchOperation{"policies": nil}, changed)
+	})
+}
@@ -0,0 +1,210 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/utils"
+)
+
+// settingsCollection groups top level fields which are not collections of entities
+const settingsCollection = "settings"
+
+type EntityChange struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name,omitempty"`
+	Kind          ChangeKind `json:"kind"`
+	ChangedFields int        `json:"changed_fields,omitempty"`
+}
+
+type CollectionSummary struct {
+	Collection    string         `json:"collection"`
+	Entities      []EntityChange `json:"entities,omitempty"`
+	ChangedFields int            `json:"changed_fields,omitempty"`
+}
+
+// Summary groups changes required to turn target into source by collection and entity
+func Summary(source models.Rfc7396PatchOperation, target models.Rfc7396PatchOperation, opts ...Option) ([]CollectionSummary, error) {
+	var (
+		options  = &Options{}
+		out      []CollectionSummary
+		settings = CollectionSummary{Collection: settingsCollection}
+		err      error
+	)
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if source, target, err = prepare(source, target, options); err != nil {
+		return nil, err
+	}
+
+	for _, key := range sortedKeys(source, target) {
+		var (
+			path    = mapIndexPath(rootPath, key)
+			sv, sok = source[key]
+			tv, tok = target[key]
+		)
+
+		if options.ignored(path) {
+			continue
+		}
+
+		if !isCollection(sv) || !isCollection(tv) {
+			settings.ChangedFields += countChanges(map[string]any{key: sv}, map[string]any{key: tv}, sok, tok, rootPath, options)
+			continue
+		}
+
+		if summary := summarizeCollection(key, utils.AsMap(sv), utils.AsMap(tv), path, options); len(summary.Entities) > 0 {
+			out = append(out, summary)
+		}
+	}
+
+	if settings.ChangedFields > 0 {
+		out = append(out, settings)
+	}
+
+	return out, nil
+}
+
+// RenderSummary renders the result of Summary in a human-readable form
+func RenderSummary(summaries []CollectionSummary) string {
+	var sb strings.Builder
+
+	for _, s := range summaries {
+		if s.Collection == settingsCollection {
+			sb.WriteString(fmt.Sprintf("%s: %d fields changed\n", s.Collection, s.ChangedFields))
+			continue
+		}
+
+		counts := map[ChangeKind]int{}
+
+		for _, e := range s.Entities {
+			counts[e.Kind]++
+		}
+
+		sb.WriteString(fmt.Sprintf("%s: %d added, %d modified, %d removed\n",
+			s.Collection, counts[ChangeAdded], counts[ChangeModified], counts[ChangeRemoved]))
+
+		for _, e := range s.Entities {
+			var label = e.ID
+
+			if e.Name != "" {
+				label = fmt.Sprintf("%s (%s)", e.Name, e.ID)
+			}
+
+			switch e.Kind {
+			case ChangeAdded:
+				sb.WriteString(fmt.Sprintf("+ %s\n", label))
+			case ChangeRemoved:
+				sb.WriteString(fmt.Sprintf("- %s\n", label))
+			case ChangeModified:
+				sb.WriteString(fmt.Sprintf("~ %s, %d fields changed\n", label, e.ChangedFields))
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+func summarizeCollection(collection string, source map[string]any, target map[string]any, path string, options *Options) CollectionSummary {
+	var summary = CollectionSummary{Collection: collection}
+
+	for _, id := range sortedKeys(source, target) {
+		var (
+			p       = mapIndexPath(path, id)
+			sv, sok = source[id]
+			tv, tok = target[id]
+			change  = EntityChange{ID: id}
+		)
+
+		if options.ignored(p) {
+			continue
+		}
+
+		switch {
+		case sok && !tok:
+			change.Kind = ChangeAdded
+			change.Name = utils.EntityName(utils.AsMap(sv))
+		case !sok && tok:
+			change.Kind = ChangeRemoved
+			change.Name = utils.EntityName(utils.AsMap(tv))
+		default:
+			if change.ChangedFields = countChanges(utils.AsMap(sv), utils.AsMap(tv), true, true, p, options); change.ChangedFields == 0 {
+				continue
+			}
+
+			change.Kind = ChangeModified
+			change.Name = utils.EntityName(utils.AsMap(sv))
+		}
+
+		summary.Entities = append(summary.Entities, change)
+	}
+
+	sort.SliceStable(summary.Entities, func(i, j int) bool {
+		return summary.Entities[i].Name < summary.Entities[j].Name
+	})
+
+	return summary
+}
+
+func countChanges(source map[string]any, target map[string]any, sok bool, tok bool, path string, options *Options) int {
+	var changes []Change
+
+	if !sok {
+		source = map[string]any{}
+	}
+
+	if !tok {
+		target = map[string]any{}
+	}
+
+	collectChanges(source, target, "", path, options, &changes)
+
+	return len(changes)
+}
+
+// isCollection returns true for maps of entities, missing values are treated as empty collections
+func isCollection(v any) bool {
+	if v == nil {
+		return true
+	}
+
+	m, ok := v.(map[string]any)
+
+	if !ok {
+		return false
+	}
+
+	for _, e := range m {
+		if _, ok := e.(map[string]any); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sortedKeys(maps ...map[string]any) []string {
+	var (
+		keys = map[string]struct{}{}
+		out  []string
+	)
+
+	for _, m := range maps {
+		for k := range m {
+			keys[k] = struct{}{}
+		}
+	}
+
+	for k := range keys {
+		out = append(out, k)
+	}
+
+	sort.Strings(out)
+
+	return out
+}
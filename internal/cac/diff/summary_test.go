@@ -0,0 +1,56 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/diff"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummary(t *testing.T) {
+	source := models.Rfc7396PatchOperation{
+		"name": "new name",
+		"clients": map[string]any{
+			"c1": map[string]any{"client_name": "Financroo", "scopes": []any{"openid"}, "trusted": true},
+			"c3": map[string]any{"client_name": "Bank"},
+		},
+		"policies": map[string]any{
+			"p1": map[string]any{"policy_name": "MFA_User"},
+		},
+	}
+	target := models.Rfc7396PatchOperation{
+		"name": "old name",
+		"clients": map[string]any{
+			"c1": map[string]any{"client_name": "Financroo", "scopes": []any{}, "trusted": false},
+			"c2": map[string]any{"client_name": "Removed"},
+		},
+		"policies": map[string]any{
+			"p1": map[string]any{"policy_name": "MFA_User"},
+		},
+	}
+
+	summaries, err := diff.Summary(source, target)
+	require.NoError(t, err)
+	require.Equal(t, []diff.CollectionSummary{
+		{
+			Collection: "clients",
+			Entities: []diff.EntityChange{
+				{ID: "c3", Name: "Bank", Kind: diff.ChangeAdded},
+				{ID: "c1", Name: "Financroo", Kind: diff.ChangeModified, ChangedFields: 2},
+				{ID: "c2", Name: "Removed", Kind: diff.ChangeRemoved},
+			},
+		},
+		{
+			Collection:    "settings",
+			ChangedFields: 1,
+		},
+	}, summaries)
+
+	require.Equal(t, `clients: 1 added, 1 modified, 1 removed
++ Bank (c3)
+~ Financroo (c1), 2 fields changed
+- Removed (c2)
+settings: 1 fields changed
+`, diff.RenderSummary(summaries))
+}
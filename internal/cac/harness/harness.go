@@ -0,0 +1,122 @@
+package harness
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-json-experiment/json"
+	ccyaml "github.com/goccy/go-yaml"
+	"github.com/pkg/errors"
+)
+
+// Result is an outcome of a single test case
+type Result struct {
+	File string
+	// Subject is the tested policy or script
+	Subject string
+	Case    string
+	// Err is nil when the test case passed
+	Err error
+}
+
+func (r Result) Passed() bool {
+	return r.Err == nil
+}
+
+func (r Result) String() string {
+	if r.Passed() {
+		return fmt.Sprintf("PASS %s / %s", r.Subject, r.Case)
+	}
+
+	return fmt.Sprintf("FAIL %s / %s (%s): %s", r.Subject, r.Case, r.File, r.Err)
+}
+
+// Failed returns the number of failed test cases
+func Failed(results []Result) int {
+	var failed int
+
+	for _, r := range results {
+		if !r.Passed() {
+			failed++
+		}
+	}
+
+	return failed
+}
+
+// WriteResults prints results of test cases followed by a summary
+func WriteResults(w io.Writer, results []Result) error {
+	for _, r := range results {
+		if _, err := fmt.Fprintln(w, r.String()); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d passed, %d failed\n", len(results)-Failed(results), Failed(results))
+
+	return err
+}
+
+// loadSuites decodes all YAML test files found in the directory, sorted by path
+// missing directory means there are no tests
+func loadSuites[T any](dir string) (map[string]T, []string, error) {
+	var (
+		suites = map[string]T{}
+		files  []string
+	)
+
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		return suites, files, nil
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || (filepath.Ext(path) != ".yaml" && filepath.Ext(path) != ".yml") {
+			return err
+		}
+
+		var (
+			suite T
+			bts   []byte
+		)
+
+		if bts, err = os.ReadFile(path); err != nil {
+			return errors.Wrapf(err, "failed to read test file %s", path)
+		}
+
+		if bts, err = ccyaml.YAMLToJSON(bts); err != nil {
+			return errors.Wrapf(err, "failed to parse test file %s", path)
+		}
+
+		if err = json.Unmarshal(bts, &suite, json.RejectUnknownMembers(true)); err != nil {
+			return errors.Wrapf(err, "failed to decode test file %s", path)
+		}
+
+		suites[path] = suite
+		files = append(files, path)
+
+		return nil
+	})
+
+	sort.Strings(files)
+
+	return suites, files, err
+}
+
+// convert round-trips the value through JSON, so values from different sources compare equal
+func convert(in any, out any) error {
+	bts, err := json.Marshal(in)
+
+	if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(bts, out); err != nil {
+		return errors.Wrapf(err, "failed to convert %s", bts)
+	}
+
+	return nil
+}
@@ -0,0 +1,307 @@
+package harness
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/rego"
+	"github.com/pkg/errors"
+)
+
+// DefaultRegoQuery is evaluated for rego policies when the test suite does not set its own query
+const DefaultRegoQuery = "data.acp.authz.allow"
+
+// PolicySuite is a test file of a policy
+type PolicySuite struct {
+	// Policy is the id or the name of the tested policy
+	Policy string `json:"policy"`
+	// Query overrides the rule of rego policies which decides if the request is allowed
+	Query string       `json:"query,omitempty"`
+	Cases []PolicyCase `json:"cases"`
+}
+
+type PolicyCase struct {
+	Name  string         `json:"name"`
+	Input map[string]any `json:"input"`
+	Allow bool           `json:"allow"`
+}
+
+// PolicyRunner evaluates policies against inputs of test cases
+// rego policies are evaluated with the embedded opa, so no opa executable is needed
+type PolicyRunner struct{}
+
+var ErrPolicyNotFound = errors.New("policy not found")
+
+// LoadPolicySuites reads policy test files from the directory
+func LoadPolicySuites(dir string) (map[string]PolicySuite, []string, error) {
+	return loadSuites[PolicySuite](dir)
+}
+
+// Run evaluates test cases of all suites, suites are run in the order of files
+func (r *PolicyRunner) Run(policies models.TreePolicies, suites map[string]PolicySuite, files []string) []Result {
+	var results []Result
+
+	for _, file := range files {
+		var (
+			suite          = suites[file]
+			id, policy, ok = findPolicy(policies, suite.Policy)
+		)
+
+		for _, c := range suite.Cases {
+			var (
+				result = Result{File: file, Subject: suite.Policy, Case: c.Name}
+				allow  bool
+				err    error
+			)
+
+			switch {
+			case !ok:
+				result.Err = errors.Wrapf(ErrPolicyNotFound, "policy %s", suite.Policy)
+			default:
+				if allow, err = r.Evaluate(policy, suite.Query, c.Input); err != nil {
+					result.Err = errors.Wrapf(err, "failed to evaluate policy %s", id)
+				} else if allow != c.Allow {
+					result.Err = errors.Errorf("expected %s, got %s", outcome(c.Allow), outcome(allow))
+				}
+			}
+
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
+
+func outcome(allow bool) string {
+	if allow {
+		return "allow"
+	}
+
+	return "deny"
+}
+
+// findPolicy returns the policy by its id or name
+func findPolicy(policies models.TreePolicies, name string) (string, models.TreePolicy, bool) {
+	if policy, ok := policies[name]; ok {
+		return name, policy, true
+	}
+
+	for id, policy := range policies {
+		if policy.PolicyName == name {
+			return id, policy, true
+		}
+	}
+
+	return "", models.TreePolicy{}, false
+}
+
+// Evaluate returns whether the policy allows the input
+func (r *PolicyRunner) Evaluate(policy models.TreePolicy, query string, input map[string]any) (bool, error) {
+	switch policy.Language {
+	case "rego":
+		return r.evaluateRego(policy.Definition, query, input)
+	case "cloudentity", "":
+		return evaluateValidators(policy.Validators, input)
+	}
+
+	return false, errors.Errorf("unsupported policy language %s", policy.Language)
+}
+
+func (r *PolicyRunner) evaluateRego(definition string, query string, input map[string]any) (bool, error) {
+	var (
+		values []any
+		err    error
+	)
+
+	if query == "" {
+		query = DefaultRegoQuery
+	}
+
+	if values, err = rego.Eval(context.Background(), query, input, rego.WithModule("policy.rego", definition)); err != nil {
+		return false, errors.Wrap(err, "failed to evaluate rego policy")
+	}
+
+	// undefined result denies the request
+	if len(values) == 0 {
+		return false, nil
+	}
+
+	allow, ok := values[0].(bool)
+
+	if !ok {
+		return false, errors.Errorf("query %s must evaluate to a boolean, got %v", query, values[0])
+	}
+
+	return allow, nil
+}
+
+// evaluateValidators evaluates policies in the Cloudentity format, all validators have to pass to allow the request
+// validator fields are resolved against the input of the test case, validators without fields depend on the server
+// and cannot be evaluated offline
+func evaluateValidators(validators []*models.ValidatorConfig, input map[string]any) (bool, error) {
+	for i, v := range validators {
+		if v == nil {
+			continue
+		}
+
+		var (
+			passed bool
+			err    error
+		)
+
+		switch v.Name {
+		case "true":
+			passed = true
+		case "false":
+			passed = false
+		default:
+			fields, ok := v.Conf["fields"]
+
+			if !ok {
+				return false, errors.Errorf("unsupported validator %s", v.Name)
+			}
+
+			if passed, err = evaluateFields(fields, input); err != nil {
+				return false, errors.Wrapf(err, "validator %d %s", i, v.Name)
+			}
+		}
+
+		if !passed {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func evaluateFields(conf any, input map[string]any) (bool, error) {
+	var fields []struct {
+		Comparator string `json:"comparator"`
+		Field      string `json:"field"`
+		Value      any    `json:"value"`
+	}
+
+	if err := convert(conf, &fields); err != nil {
+		return false, errors.Wrap(err, "invalid fields")
+	}
+
+	for _, f := range fields {
+		var (
+			found, present = lookup(input, f.Field)
+			value          any
+			expected       any
+			passed         bool
+			err            error
+		)
+
+		if err = convert(found, &value); err != nil {
+			return false, errors.Wrapf(err, "invalid input of field %s", f.Field)
+		}
+
+		if err = convert(f.Value, &expected); err != nil {
+			return false, errors.Wrapf(err, "invalid value of field %s", f.Field)
+		}
+
+		if passed, err = compare(f.Comparator, value, present, expected); err != nil {
+			return false, errors.Wrapf(err, "field %s", f.Field)
+		}
+
+		if !passed {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func compare(comparator string, value any, present bool, expected any) (bool, error) {
+	switch comparator {
+	case "equal":
+		return present && reflect.DeepEqual(value, expected), nil
+	case "not_equal":
+		return !present || !reflect.DeepEqual(value, expected), nil
+	case "contains":
+		return present && contains(value, expected), nil
+	case "not_contains":
+		return !present || !contains(value, expected), nil
+	case "present":
+		return present, nil
+	case "not_present":
+		return !present, nil
+	case "regexp":
+		var (
+			pattern, ok = expected.(string)
+			s, isString = value.(string)
+			re          *regexp.Regexp
+			err         error
+		)
+
+		if !ok {
+			return false, errors.New("regexp value must be a string")
+		}
+
+		if re, err = regexp.Compile(pattern); err != nil {
+			return false, err
+		}
+
+		return present && isString && re.MatchString(s), nil
+	}
+
+	return false, errors.Errorf("unsupported comparator %s", comparator)
+}
+
+// contains returns true if the string contains the substring or the list contains the value, or all values of the list
+func contains(value any, expected any) bool {
+	switch v := value.(type) {
+	case string:
+		s, ok := expected.(string)
+		return ok && strings.Contains(v, s)
+	case []any:
+		var wanted = []any{expected}
+
+		if list, ok := expected.([]any); ok {
+			wanted = list
+		}
+
+		for _, w := range wanted {
+			var found bool
+
+			for _, item := range v {
+				if reflect.DeepEqual(item, w) {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// lookup returns the value of the dot separated field of the input
+func lookup(input map[string]any, field string) (any, bool) {
+	var current any = input
+
+	for _, key := range strings.Split(field, ".") {
+		m, ok := current.(map[string]any)
+
+		if !ok {
+			return nil, false
+		}
+
+		if current, ok = m[key]; !ok || current == nil {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
@@ -0,0 +1,214 @@
+package harness_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/harness"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyRunner(t *testing.T) {
+	var (
+		dir      = t.TempDir()
+		policies = models.TreePolicies{
+			"mfa": {
+				PolicyName: "Require MFA",
+				Language:   "cloudentity",
+				Validators: []*models.ValidatorConfig{
+					{Name: "true"},
+					{
+						Name: "identity-context",
+						Conf: map[string]any{
+							"fields": []any{
+								map[string]any{"comparator": "contains", "field": "login.verified_recovery_methods", "value": []any{"mfa"}},
+							},
+						},
+						Recovery: []*models.RecoveryConfig{{Type: "mfa"}},
+					},
+				},
+			},
+			"allow": {
+				PolicyName: "Allow",
+				Language:   "cloudentity",
+				Validators: []*models.ValidatorConfig{{Name: "true"}},
+			},
+			"deny": {
+				PolicyName: "Deny",
+				Language:   "cloudentity",
+				Validators: []*models.ValidatorConfig{{Name: "true"}, {Name: "false"}},
+			},
+		}
+	)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "nested"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "allow.yaml"), []byte(`policy: Allow
+cases:
+  - name: always allowed
+    input: {}
+    allow: true
+  - name: wrong expectation
+    input: {}
+    allow: false
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "mfa.yaml"), []byte(`policy: Require MFA
+cases:
+  - name: user with mfa
+    input:
+      login:
+        verified_recovery_methods: [password, mfa]
+    allow: true
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "nested", "other.yaml"), []byte(`policy: deny
+cases:
+  - name: always denied
+    input: {}
+    allow: false
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "missing.yaml"), []byte(`policy: missing
+cases:
+  - name: any
+    input: {}
+`), 0600))
+
+	suites, files, err := harness.LoadPolicySuites(dir)
+	require.NoError(t, err)
+
+	results := (&harness.PolicyRunner{}).Run(policies, suites, files)
+
+	require.Len(t, results, 5)
+	require.True(t, results[0].Passed(), results[0].String())
+	require.EqualError(t, results[1].Err, "expected deny, got allow")
+	require.True(t, results[2].Passed(), results[2].String())
+	require.ErrorIs(t, results[3].Err, harness.ErrPolicyNotFound)
+	require.True(t, results[4].Passed(), results[4].String())
+	require.Equal(t, 2, harness.Failed(results))
+}
+
+func TestEvaluateValidators(t *testing.T) {
+	var (
+		runner = &harness.PolicyRunner{}
+		input  = map[string]any{
+			"login": map[string]any{
+				"verified_recovery_methods": []any{"password", "mfa"},
+			},
+			"user": map[string]any{
+				"email": "jane@example.com",
+				"age":   30,
+			},
+		}
+		policy = func(comparator string, field string, value any) models.TreePolicy {
+			return models.TreePolicy{
+				Language: "cloudentity",
+				Validators: []*models.ValidatorConfig{{
+					Name: "identity-context",
+					Conf: map[string]any{
+						"fields": []any{map[string]any{"comparator": comparator, "field": field, "value": value}},
+					},
+				}},
+			}
+		}
+	)
+
+	for _, tc := range []struct {
+		name       string
+		comparator string
+		field      string
+		value      any
+		allow      bool
+	}{
+		{name: "equal", comparator: "equal", field: "user.email", value: "jane@example.com", allow: true},
+		{name: "equal number", comparator: "equal", field: "user.age", value: 30, allow: true},
+		{name: "equal missing", comparator: "equal", field: "user.name", value: "jane", allow: false},
+		{name: "not equal", comparator: "not_equal", field: "user.email", value: "john@example.com", allow: true},
+		{name: "not equal same", comparator: "not_equal", field: "user.email", value: "jane@example.com", allow: false},
+		{name: "contains item", comparator: "contains", field: "login.verified_recovery_methods", value: "mfa", allow: true},
+		{name: "contains all items", comparator: "contains", field: "login.verified_recovery_methods", value: []any{"mfa", "otp"}, allow: false},
+		{name: "contains substring", comparator: "contains", field: "user.email", value: "@example.com", allow: true},
+		{name: "not contains", comparator: "not_contains", field: "login.verified_recovery_methods", value: "otp", allow: true},
+		{name: "not contains present", comparator: "not_contains", field: "login.verified_recovery_methods", value: "mfa", allow: false},
+		{name: "present", comparator: "present", field: "user.email", allow: true},
+		{name: "present missing", comparator: "present", field: "user.phone", allow: false},
+		{name: "not present", comparator: "not_present", field: "user.phone", allow: true},
+		{name: "not present existing", comparator: "not_present", field: "user.email", allow: false},
+		{name: "regexp", comparator: "regexp", field: "user.email", value: "^[a-z]+@example\\.com$", allow: true},
+		{name: "regexp not matching", comparator: "regexp", field: "user.email", value: "@other\\.com$", allow: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			allow, err := runner.Evaluate(policy(tc.comparator, tc.field, tc.value), "", input)
+			require.NoError(t, err)
+			require.Equal(t, tc.allow, allow)
+		})
+	}
+
+	t.Run("unsupported comparator", func(t *testing.T) {
+		_, err := runner.Evaluate(policy("greater", "user.age", 18), "", input)
+		require.ErrorContains(t, err, "unsupported comparator greater")
+	})
+
+	t.Run("invalid regexp", func(t *testing.T) {
+		_, err := runner.Evaluate(policy("regexp", "user.email", "("), "", input)
+		require.ErrorContains(t, err, "field user.email")
+	})
+
+	t.Run("validator without fields", func(t *testing.T) {
+		_, err := runner.Evaluate(models.TreePolicy{
+			Language:   "cloudentity",
+			Validators: []*models.ValidatorConfig{{Name: "ip-range", Conf: map[string]any{"ranges": []any{"10.0.0.0/8"}}}},
+		}, "", input)
+		require.EqualError(t, err, "unsupported validator ip-range")
+	})
+}
+
+func TestLoadPolicySuites(t *testing.T) {
+	t.Run("missing directory", func(t *testing.T) {
+		suites, files, err := harness.LoadPolicySuites(filepath.Join(t.TempDir(), "missing"))
+		require.NoError(t, err)
+		require.Empty(t, suites)
+		require.Empty(t, files)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "typo.yaml"), []byte("policy: p\ncasses: []\n"), 0600))
+
+		_, _, err := harness.LoadPolicySuites(dir)
+		require.ErrorContains(t, err, "failed to decode test file")
+	})
+}
+
+func TestEvaluateRego(t *testing.T) {
+	var (
+		runner = &harness.PolicyRunner{}
+		policy = models.TreePolicy{Language: "rego", Definition: `package acp.authz
+
+default allow = false
+
+allow {
+	input.role == "admin"
+}
+
+custom := input.role
+`}
+	)
+
+	allow, err := runner.Evaluate(policy, "", map[string]any{"role": "admin"})
+	require.NoError(t, err)
+	require.True(t, allow)
+
+	allow, err = runner.Evaluate(policy, "", map[string]any{"role": "user"})
+	require.NoError(t, err)
+	require.False(t, allow)
+
+	allow, err = runner.Evaluate(policy, "data.acp.authz.undefined", map[string]any{"role": "admin"})
+	require.NoError(t, err)
+	require.False(t, allow)
+
+	_, err = runner.Evaluate(policy, "data.acp.authz.custom", map[string]any{"role": "admin"})
+	require.ErrorContains(t, err, "must evaluate to a boolean")
+
+	_, err = runner.Evaluate(models.TreePolicy{Language: "rego", Definition: "package acp.authz\n\nallow {"}, "", map[string]any{})
+	require.ErrorContains(t, err, "failed to evaluate rego policy")
+}
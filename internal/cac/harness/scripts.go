@@ -0,0 +1,306 @@
+package harness
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/dop251/goja"
+	"github.com/go-json-experiment/json"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slog"
+)
+
+// DefaultScriptTimeout limits the execution time of a single test case
+const DefaultScriptTimeout = 5 * time.Second
+
+// ScriptSuite is a test file of a script
+type ScriptSuite struct {
+	// Script is the id or the name of the tested script
+	Script string `json:"script"`
+	// ExecutionPoint is the execution point type used to mock the context
+	// it defaults to the type the script is attached to in script execution points
+	ExecutionPoint string       `json:"execution_point,omitempty"`
+	Cases          []ScriptCase `json:"cases"`
+}
+
+type ScriptCase struct {
+	Name string `json:"name"`
+	// Context is merged into the mocked context of the execution point
+	Context map[string]any `json:"context,omitempty"`
+	// Output is the expected value returned by the script
+	Output any `json:"output,omitempty"`
+	// Error is expected to be a part of the error thrown by the script
+	Error string `json:"error,omitempty"`
+}
+
+// MockContexts are contexts passed to scripts attached to execution points of the given types
+// values of test cases are merged into them, so only the relevant parts have to be provided
+var MockContexts = map[string]map[string]any{
+	"post_authn_ctx": {
+		"authn_ctx": map[string]any{},
+		"idp":       map[string]any{},
+		"request":   map[string]any{"headers": map[string]any{}},
+		"secrets":   map[string]any{},
+	},
+	"token_minting": {
+		"access_token": map[string]any{},
+		"id_token":     map[string]any{},
+		"authn_ctx":    map[string]any{},
+		"client":       map[string]any{},
+		"request":      map[string]any{"headers": map[string]any{}},
+		"secrets":      map[string]any{},
+	},
+	"allowed_idp_ids": {
+		"client":  map[string]any{},
+		"idps":    []any{},
+		"request": map[string]any{"headers": map[string]any{}},
+		"secrets": map[string]any{},
+	},
+}
+
+// ScriptRunner executes scripts with mocked contexts in an embedded JavaScript runtime
+type ScriptRunner struct {
+	Timeout time.Duration
+}
+
+var ErrScriptNotFound = errors.New("script not found")
+
+// LoadScriptSuites reads script test files from the directory
+func LoadScriptSuites(dir string) (map[string]ScriptSuite, []string, error) {
+	return loadSuites[ScriptSuite](dir)
+}
+
+// Run executes test cases of all suites, suites are run in the order of files
+func (r *ScriptRunner) Run(server *models.TreeServer, suites map[string]ScriptSuite, files []string) []Result {
+	var results []Result
+
+	for _, file := range files {
+		var (
+			suite          = suites[file]
+			id, script, ok = findScript(server.Scripts, suite.Script)
+			point          = suite.ExecutionPoint
+		)
+
+		if ok && point == "" {
+			point = executionPointType(server.ScriptExecutionPoints, id)
+		}
+
+		for _, c := range suite.Cases {
+			var (
+				result = Result{File: file, Subject: suite.Script, Case: c.Name}
+				output any
+				err    error
+			)
+
+			if !ok {
+				result.Err = errors.Wrapf(ErrScriptNotFound, "script %s", suite.Script)
+				results = append(results, result)
+				continue
+			}
+
+			output, err = r.Execute(script.Body, mockContext(point, c.Context))
+			result.Err = assertOutput(c, output, err)
+
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
+
+// findScript returns the script by its id or name
+func findScript(scripts models.TreeScripts, name string) (string, models.TreeScript, bool) {
+	if script, ok := scripts[name]; ok {
+		return name, script, true
+	}
+
+	for id, script := range scripts {
+		if script.Name == name {
+			return id, script, true
+		}
+	}
+
+	return "", models.TreeScript{}, false
+}
+
+// executionPointType returns the first type, in alphabetical order, of execution points the script is attached to
+func executionPointType(points models.TreeScriptExecutionPoints, id string) string {
+	var types = make([]string, 0, len(points))
+
+	for typ := range points {
+		types = append(types, typ)
+	}
+
+	sort.Strings(types)
+
+	for _, typ := range types {
+		for _, point := range points[typ] {
+			if point.ScriptID == id {
+				return typ
+			}
+		}
+	}
+
+	return ""
+}
+
+// mockContext returns the mocked context of the execution point type with values of the test case merged into it
+func mockContext(point string, values map[string]any) map[string]any {
+	var out = map[string]any{}
+
+	// mocks are copied, as scripts may modify the context
+	if err := convert(MockContexts[point], &out); err != nil || out == nil {
+		out = map[string]any{}
+	}
+
+	return merge(out, values)
+}
+
+func merge(dst map[string]any, src map[string]any) map[string]any {
+	for k, v := range src {
+		if sv, ok := v.(map[string]any); ok {
+			if dv, isMap := dst[k].(map[string]any); isMap {
+				dst[k] = merge(dv, sv)
+				continue
+			}
+		}
+
+		dst[k] = v
+	}
+
+	return dst
+}
+
+func assertOutput(c ScriptCase, output any, err error) error {
+	var expected any
+
+	if c.Error != "" {
+		if err == nil {
+			return errors.Errorf("expected error %q, got output %s", c.Error, compact(output))
+		}
+
+		if !strings.Contains(err.Error(), c.Error) {
+			return errors.Errorf("expected error %q, got %q", c.Error, err)
+		}
+
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err = convert(c.Output, &expected); err != nil {
+		return errors.Wrap(err, "invalid expected output")
+	}
+
+	if !reflect.DeepEqual(expected, output) {
+		return errors.Errorf("expected output %s, got %s", compact(expected), compact(output))
+	}
+
+	return nil
+}
+
+func compact(v any) string {
+	bts, err := json.Marshal(v, json.Deterministic(true))
+
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(bts)
+}
+
+// Execute runs the script exported with module.exports and returns its result, awaiting returned promises
+func (r *ScriptRunner) Execute(body string, context map[string]any) (any, error) {
+	var (
+		vm      = goja.New()
+		module  = vm.NewObject()
+		exports = vm.NewObject()
+		timeout = r.Timeout
+		fn      goja.Callable
+		value   goja.Value
+		output  any
+		ok      bool
+		err     error
+	)
+
+	if timeout == 0 {
+		timeout = DefaultScriptTimeout
+	}
+
+	timer := time.AfterFunc(timeout, func() {
+		vm.Interrupt(errors.Errorf("script timed out after %s", timeout))
+	})
+	defer timer.Stop()
+
+	if err = setupRuntime(vm, module, exports); err != nil {
+		return nil, err
+	}
+
+	if _, err = vm.RunString(body); err != nil {
+		return nil, errors.Wrap(err, "failed to run script")
+	}
+
+	if fn, ok = goja.AssertFunction(module.Get("exports")); !ok {
+		return nil, errors.New("script must export a function with module.exports")
+	}
+
+	if value, err = fn(goja.Undefined(), vm.ToValue(context)); err != nil {
+		return nil, errors.Wrap(err, "script failed")
+	}
+
+	if promise, isPromise := value.Export().(*goja.Promise); isPromise {
+		switch promise.State() {
+		case goja.PromiseStateRejected:
+			return nil, errors.Errorf("script failed: %s", promise.Result())
+		case goja.PromiseStatePending:
+			return nil, errors.New("script returned a promise which never resolved")
+		}
+
+		value = promise.Result()
+	}
+
+	// normalize exported values, so they compare equal to decoded fixtures
+	if err = convert(value.Export(), &output); err != nil {
+		return nil, errors.Wrap(err, "failed to convert script output")
+	}
+
+	return output, nil
+}
+
+func setupRuntime(vm *goja.Runtime, module *goja.Object, exports *goja.Object) error {
+	var console = vm.NewObject()
+
+	for _, level := range []string{"log", "info", "debug", "warn", "error"} {
+		if err := console.Set(level, func(call goja.FunctionCall) goja.Value {
+			args := make([]any, 0, len(call.Arguments))
+
+			for _, a := range call.Arguments {
+				args = append(args, a.Export())
+			}
+
+			slog.Debug("script console", "args", args)
+
+			return goja.Undefined()
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := module.Set("exports", exports); err != nil {
+		return err
+	}
+
+	for name, value := range map[string]any{"module": module, "exports": exports, "console": console} {
+		if err := vm.Set(name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
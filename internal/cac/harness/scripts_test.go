@@ -0,0 +1,99 @@
+package harness_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/harness"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScriptRunner(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		server = &models.TreeServer{
+			Scripts: models.TreeScripts{
+				"s1": {
+					Name: "mint",
+					Body: `module.exports = async function(context) {
+  console.log("minting", context.client);
+  if (!context.authn_ctx.email) {
+    throw new Error("email is required");
+  }
+  return {
+    access_token: {email: context.authn_ctx.email, tokens: Object.keys(context).length},
+  };
+};`,
+				},
+				"s2": {
+					Name: "sync",
+					Body: `module.exports = function(context) { return context.idps.length; };`,
+				},
+			},
+			ScriptExecutionPoints: models.TreeScriptExecutionPoints{
+				"token_minting": {"default": {ScriptID: "s1"}},
+			},
+		}
+	)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "mint.yaml"), []byte(`script: mint
+cases:
+  - name: adds email
+    context:
+      authn_ctx:
+        email: jdoe@example.com
+    output:
+      access_token:
+        email: jdoe@example.com
+        tokens: 6
+  - name: requires email
+    error: email is required
+  - name: wrong output
+    context:
+      authn_ctx:
+        email: other@example.com
+    output:
+      access_token:
+        email: jdoe@example.com
+        tokens: 6
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sync.yaml"), []byte(`script: s2
+execution_point: allowed_idp_ids
+cases:
+  - name: counts idps
+    context:
+      idps: [a, b]
+    output: 2
+`), 0600))
+
+	suites, files, err := harness.LoadScriptSuites(dir)
+	require.NoError(t, err)
+
+	results := (&harness.ScriptRunner{}).Run(server, suites, files)
+
+	require.Len(t, results, 4)
+	require.True(t, results[0].Passed(), results[0].String())
+	require.True(t, results[1].Passed(), results[1].String())
+	require.EqualError(t, results[2].Err, `expected output {"access_token":{"email":"jdoe@example.com","tokens":6}}, got {"access_token":{"email":"other@example.com","tokens":6}}`)
+	require.True(t, results[3].Passed(), results[3].String())
+}
+
+func TestExecute(t *testing.T) {
+	t.Run("missing export", func(t *testing.T) {
+		_, err := (&harness.ScriptRunner{}).Execute(`var x = 1;`, map[string]any{})
+		require.ErrorContains(t, err, "module.exports")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		_, err := (&harness.ScriptRunner{Timeout: 50 * time.Millisecond}).Execute(`module.exports = function() { while (true) {} };`, map[string]any{})
+		require.ErrorContains(t, err, "timed out")
+	})
+
+	t.Run("rejected promise", func(t *testing.T) {
+		_, err := (&harness.ScriptRunner{}).Execute(`module.exports = async function() { throw "boom"; };`, map[string]any{})
+		require.ErrorContains(t, err, "boom")
+	})
+}
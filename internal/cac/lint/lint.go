@@ -0,0 +1,182 @@
+package lint
+
+import (
+	"sort"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/provenance"
+	"github.com/cloudentity/cac/internal/cac/report"
+	"github.com/cloudentity/cac/internal/cac/utils"
+	"github.com/pkg/errors"
+)
+
+type Configuration struct {
+	// Rules configures built-in rules by their ids, rules which are not listed use their defaults
+	Rules map[string]RuleConfiguration `json:"rules"`
+}
+
+type RuleConfiguration struct {
+	// Enabled enables or disables the rule, rules listed in the configuration are enabled by default
+	Enabled *bool `json:"enabled"`
+	// Severity overrides the default severity of the rule
+	Severity report.Severity `json:"severity"`
+	// Params are rule specific parameters
+	Params map[string]string `json:"params"`
+}
+
+var DefaultConfig = func() *Configuration {
+	return &Configuration{
+		Rules: map[string]RuleConfiguration{},
+	}
+}
+
+var ErrUnknownRule = errors.New("unknown lint rule")
+
+// Params are rule specific parameters
+type Params map[string]string
+
+// Emit reports a finding of the rule, the pointer is relative to the workspace configuration
+type Emit func(pointer string, message string)
+
+// Rule checks the decoded workspace configuration
+type Rule struct {
+	ID          string
+	Description string
+	Severity    report.Severity
+	// Enabled is the default state of the rule
+	Enabled bool
+	// Init validates params and returns the check of the configuration
+	Init func(params Params) (func(server *models.TreeServer, emit Emit), error)
+}
+
+type check struct {
+	rule     Rule
+	severity report.Severity
+	fn       func(server *models.TreeServer, emit Emit)
+}
+
+// Linter runs enabled rules against the configuration
+type Linter struct {
+	checks []check
+}
+
+func New(config *Configuration) (*Linter, error) {
+	var (
+		l     = &Linter{}
+		rules = map[string]Rule{}
+		err   error
+	)
+
+	for _, r := range Rules {
+		rules[r.ID] = r
+	}
+
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	for id := range config.Rules {
+		if _, ok := rules[id]; !ok {
+			return nil, errors.Wrapf(ErrUnknownRule, "rule %s", id)
+		}
+	}
+
+	for _, r := range Rules {
+		var (
+			conf, listed = config.Rules[r.ID]
+			enabled      = r.Enabled || listed
+			c            = check{rule: r, severity: r.Severity}
+		)
+
+		if conf.Enabled != nil {
+			enabled = *conf.Enabled
+		}
+
+		if !enabled {
+			continue
+		}
+
+		if conf.Severity != "" {
+			if conf.Severity != report.SeverityError && conf.Severity != report.SeverityWarning {
+				return nil, errors.Errorf("invalid severity %s of lint rule %s, supported severities: error, warning", conf.Severity, r.ID)
+			}
+
+			c.severity = conf.Severity
+		}
+
+		if c.fn, err = r.Init(conf.Params); err != nil {
+			return nil, errors.Wrapf(err, "invalid params of lint rule %s", r.ID)
+		}
+
+		l.checks = append(l.checks, c)
+	}
+
+	return l, nil
+}
+
+// Lint decodes the workspace or tenant configuration and adds findings of enabled rules to the report
+// findings suppressed with a comment in the source file or with the cac_lint_disable client metadata are skipped
+func (l *Linter) Lint(data models.Rfc7396PatchOperation, tenant bool, positions provenance.Map, rep *report.Report) error {
+	var (
+		normalized models.Rfc7396PatchOperation
+		servers    = map[string]*models.TreeServer{}
+		err        error
+	)
+
+	// decoding cleans the patch, so a copy is used
+	if normalized, err = utils.NormalizePatch(data); err != nil {
+		return err
+	}
+
+	if tenant {
+		var t *models.TreeTenant
+
+		if t, err = utils.FromPatchToModel[models.TreeTenant](normalized); err != nil {
+			return err
+		}
+
+		for id, server := range t.Servers {
+			servers[provenance.Join("/servers", id)] = &server
+		}
+	} else {
+		var server *models.TreeServer
+
+		if server, err = utils.FromPatchToModel[models.TreeServer](normalized); err != nil {
+			return err
+		}
+
+		servers[""] = server
+	}
+
+	var (
+		prefixes   = make([]string, 0, len(servers))
+		suppressor = newSuppressor(positions)
+	)
+
+	for prefix := range servers {
+		prefixes = append(prefixes, prefix)
+	}
+
+	sort.Strings(prefixes)
+
+	for _, prefix := range prefixes {
+		server := servers[prefix]
+
+		for _, c := range l.checks {
+			c.fn(server, func(pointer string, message string) {
+				if suppressor.suppressed(c.rule.ID, server, prefix, pointer) {
+					return
+				}
+
+				rep.Add(report.Issue{
+					Pointer:  prefix + pointer,
+					Rule:     c.rule.ID,
+					Severity: c.severity,
+					Message:  message,
+				}, positions)
+			})
+		}
+	}
+
+	return nil
+}
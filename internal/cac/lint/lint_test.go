@@ -0,0 +1,165 @@
+package lint_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/lint"
+	"github.com/cloudentity/cac/internal/cac/provenance"
+	"github.com/cloudentity/cac/internal/cac/report"
+	"github.com/stretchr/testify/require"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func issues(rep *report.Report) []string {
+	var out []string
+
+	for _, i := range rep.Issues {
+		out = append(out, string(i.Severity)+" "+i.Rule+" "+i.Pointer)
+	}
+
+	return out
+}
+
+func TestNew(t *testing.T) {
+	t.Run("unknown rule", func(t *testing.T) {
+		_, err := lint.New(&lint.Configuration{Rules: map[string]lint.RuleConfiguration{"unknown": {}}})
+		require.ErrorIs(t, err, lint.ErrUnknownRule)
+	})
+
+	t.Run("invalid severity", func(t *testing.T) {
+		_, err := lint.New(&lint.Configuration{Rules: map[string]lint.RuleConfiguration{"no-implicit-grant": {Severity: "fatal"}}})
+		require.ErrorContains(t, err, "invalid severity")
+	})
+
+	t.Run("missing params", func(t *testing.T) {
+		_, err := lint.New(&lint.Configuration{Rules: map[string]lint.RuleConfiguration{"client-name-pattern": {}}})
+		require.ErrorContains(t, err, "pattern is required")
+	})
+
+	t.Run("disabled rule with missing params", func(t *testing.T) {
+		_, err := lint.New(&lint.Configuration{Rules: map[string]lint.RuleConfiguration{"client-name-pattern": {Enabled: ptr(false)}}})
+		require.NoError(t, err)
+	})
+}
+
+func TestLint(t *testing.T) {
+	var data = models.Rfc7396PatchOperation{
+		"grant_types":      []any{"implicit"},
+		"access_token_ttl": "2h",
+		"clients": map[string]any{
+			"c1": map[string]any{
+				"client_name":   "team-one",
+				"grant_types":   []any{"authorization_code"},
+				"redirect_uris": []any{"https://example.com/cb", "http://localhost:8080/cb", "http://example.com/cb"},
+			},
+			"c2": map[string]any{
+				"client_name":   "two",
+				"grant_types":   []any{"implicit"},
+				"redirect_uris": []any{"com.example.app:/cb"},
+				"token_ttls":    map[string]any{"access_token_ttl": "30m", "refresh_token_ttl": "720h"},
+				"metadata":      map[string]any{"cac_lint_disable": []any{"https-redirect-uris"}},
+			},
+		},
+	}
+
+	t.Run("default rules", func(t *testing.T) {
+		var (
+			rep    = &report.Report{}
+			l, err = lint.New(nil)
+		)
+		require.NoError(t, err)
+
+		require.NoError(t, l.Lint(data, false, provenance.Map{}, rep))
+		require.Equal(t, []string{
+			"error no-implicit-grant /grant_types/0",
+			"error no-implicit-grant /clients/c2/grant_types/0",
+			"error https-redirect-uris /clients/c1/redirect_uris/2",
+		}, issues(rep))
+	})
+
+	t.Run("configured rules", func(t *testing.T) {
+		var (
+			rep    = &report.Report{}
+			l, err = lint.New(&lint.Configuration{Rules: map[string]lint.RuleConfiguration{
+				"no-implicit-grant":   {Enabled: ptr(false)},
+				"https-redirect-uris": {Severity: report.SeverityWarning, Params: map[string]string{"allow_localhost": "false"}},
+				"max-token-ttl":       {Params: map[string]string{"access_token": "1h", "refresh_token": "168h"}},
+				"client-name-pattern": {Params: map[string]string{"pattern": "^team-"}},
+			}})
+		)
+		require.NoError(t, err)
+
+		require.NoError(t, l.Lint(data, false, provenance.Map{}, rep))
+		require.Equal(t, []string{
+			"warning https-redirect-uris /clients/c1/redirect_uris/1",
+			"warning https-redirect-uris /clients/c1/redirect_uris/2",
+			"error max-token-ttl /access_token_ttl",
+			"error max-token-ttl /clients/c2/token_ttls/refresh_token_ttl",
+			"warning client-name-pattern /clients/c2/client_name",
+		}, issues(rep))
+	})
+
+	t.Run("tenant", func(t *testing.T) {
+		var (
+			rep    = &report.Report{}
+			l, err = lint.New(nil)
+		)
+		require.NoError(t, err)
+
+		require.NoError(t, l.Lint(models.Rfc7396PatchOperation{
+			"servers": map[string]any{"w": map[string]any{"grant_types": []any{"implicit"}}},
+		}, true, provenance.Map{}, rep))
+		require.Equal(t, []string{"error no-implicit-grant /servers/w/grant_types/0"}, issues(rep))
+	})
+}
+
+func TestSuppressComments(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		one  = filepath.Join(dir, "one.yaml")
+		two  = filepath.Join(dir, "two.yaml")
+		data = models.Rfc7396PatchOperation{
+			"clients": map[string]any{
+				"c1": map[string]any{"grant_types": []any{"implicit", "implicit"}, "redirect_uris": []any{"http://example.com/cb"}},
+				"c2": map[string]any{"grant_types": []any{"implicit"}},
+			},
+		}
+		positions = provenance.Map{}
+		rep       = &report.Report{}
+	)
+
+	require.NoError(t, os.WriteFile(one, []byte(`grant_types:
+  - implicit # cac-lint-disable no-implicit-grant
+  # cac-lint-disable
+  - implicit
+redirect_uris:
+  - http://example.com/cb
+`), 0600))
+	require.NoError(t, os.WriteFile(two, []byte(`# cac-lint-disable-file
+grant_types:
+  - implicit
+`), 0600))
+
+	for file, id := range map[string]string{one: "c1", two: "c2"} {
+		bts, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		m, err := provenance.FromYAML(file, bts)
+		require.NoError(t, err)
+
+		positions.Merge("/clients/"+id, m)
+	}
+
+	l, err := lint.New(nil)
+	require.NoError(t, err)
+
+	require.NoError(t, l.Lint(data, false, positions, rep))
+	require.Equal(t, []string{"error https-redirect-uris /clients/c1/redirect_uris/0"}, issues(rep))
+	require.Equal(t, 6, rep.Issues[0].Line)
+}
@@ -0,0 +1,210 @@
+package lint
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/provenance"
+	"github.com/cloudentity/cac/internal/cac/report"
+	"github.com/go-openapi/strfmt"
+	"github.com/pkg/errors"
+)
+
+// Rules are the built-in lint rules
+var Rules = []Rule{
+	{
+		ID:          "no-implicit-grant",
+		Description: "Workspaces and clients must not use the implicit grant",
+		Severity:    report.SeverityError,
+		Enabled:     true,
+		Init:        noImplicitGrant,
+	},
+	{
+		ID:          "https-redirect-uris",
+		Description: "Client redirect URIs must use https, params: allow_localhost (default true)",
+		Severity:    report.SeverityError,
+		Enabled:     true,
+		Init:        httpsRedirectURIs,
+	},
+	{
+		ID:          "max-token-ttl",
+		Description: "Token TTLs must not exceed the maximum, params: access_token, id_token, refresh_token, authorization_code",
+		Severity:    report.SeverityError,
+		Init:        maxTokenTTL,
+	},
+	{
+		ID:          "client-name-pattern",
+		Description: "Client names must match the regular expression, params: pattern",
+		Severity:    report.SeverityWarning,
+		Init:        clientNamePattern,
+	},
+}
+
+func noImplicitGrant(_ Params) (func(server *models.TreeServer, emit Emit), error) {
+	return func(server *models.TreeServer, emit Emit) {
+		for i, grant := range server.GrantTypes {
+			if grant == "implicit" {
+				emit(provenance.Join("", "grant_types", strconv.Itoa(i)), "workspace allows the implicit grant")
+			}
+		}
+
+		forEachClient(server, func(id string, client models.TreeClient) {
+			for i, grant := range client.GrantTypes {
+				if grant == "implicit" {
+					emit(provenance.Join("", "clients", id, "grant_types", strconv.Itoa(i)), fmt.Sprintf("client %s uses the implicit grant", id))
+				}
+			}
+		})
+	}, nil
+}
+
+func httpsRedirectURIs(params Params) (func(server *models.TreeServer, emit Emit), error) {
+	var (
+		allowLocalhost = true
+		err            error
+	)
+
+	if v, ok := params["allow_localhost"]; ok {
+		if allowLocalhost, err = strconv.ParseBool(v); err != nil {
+			return nil, errors.Wrap(err, "invalid allow_localhost")
+		}
+	}
+
+	return func(server *models.TreeServer, emit Emit) {
+		forEachClient(server, func(id string, client models.TreeClient) {
+			for i, uri := range client.RedirectUris {
+				u, err := url.Parse(uri)
+
+				if err == nil && (u.Scheme == "https" || (allowLocalhost && u.Scheme == "http" && isLoopback(u.Hostname()))) {
+					continue
+				}
+
+				emit(provenance.Join("", "clients", id, "redirect_uris", strconv.Itoa(i)), fmt.Sprintf("client %s redirect uri %s does not use https", id, uri))
+			}
+		})
+	}, nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+func maxTokenTTL(params Params) (func(server *models.TreeServer, emit Emit), error) {
+	var limits = map[string]time.Duration{}
+
+	for _, token := range []string{"access_token", "id_token", "refresh_token", "authorization_code"} {
+		v, ok := params[token]
+
+		if !ok {
+			continue
+		}
+
+		d, err := time.ParseDuration(v)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s", token)
+		}
+
+		limits[token] = d
+	}
+
+	if len(limits) == 0 {
+		return nil, errors.New("at least one of access_token, id_token, refresh_token, authorization_code is required")
+	}
+
+	return func(server *models.TreeServer, emit Emit) {
+		check := func(subject string, pointer string, ttls map[string]strfmt.Duration) {
+			for _, token := range sortedTokens(ttls) {
+				limit, ok := limits[token]
+
+				if !ok || time.Duration(ttls[token]) <= limit {
+					continue
+				}
+
+				emit(provenance.Join(pointer, token+"_ttl"), fmt.Sprintf("%s %s ttl %s exceeds %s", subject, token, time.Duration(ttls[token]), limit))
+			}
+		}
+
+		check("workspace", "", map[string]strfmt.Duration{
+			"access_token":       server.AccessTokenTTL,
+			"id_token":           server.IDTokenTTL,
+			"refresh_token":      server.RefreshTokenTTL,
+			"authorization_code": server.AuthorizationCodeTTL,
+		})
+
+		forEachClient(server, func(id string, client models.TreeClient) {
+			if client.TokenTtls == nil {
+				return
+			}
+
+			check("client "+id, provenance.Join("", "clients", id, "token_ttls"), map[string]strfmt.Duration{
+				"access_token":       client.TokenTtls.AccessTokenTTL,
+				"id_token":           client.TokenTtls.IDTokenTTL,
+				"refresh_token":      client.TokenTtls.RefreshTokenTTL,
+				"authorization_code": client.TokenTtls.AuthorizationCodeTTL,
+			})
+		})
+	}, nil
+}
+
+func sortedTokens(ttls map[string]strfmt.Duration) []string {
+	var out = make([]string, 0, len(ttls))
+
+	for token := range ttls {
+		out = append(out, token)
+	}
+
+	sort.Strings(out)
+
+	return out
+}
+
+func clientNamePattern(params Params) (func(server *models.TreeServer, emit Emit), error) {
+	var (
+		pattern *regexp.Regexp
+		err     error
+	)
+
+	if params["pattern"] == "" {
+		return nil, errors.New("pattern is required")
+	}
+
+	if pattern, err = regexp.Compile(params["pattern"]); err != nil {
+		return nil, errors.Wrap(err, "invalid pattern")
+	}
+
+	return func(server *models.TreeServer, emit Emit) {
+		forEachClient(server, func(id string, client models.TreeClient) {
+			if !pattern.MatchString(client.ClientName) {
+				emit(provenance.Join("", "clients", id, "client_name"), fmt.Sprintf("client %s name %q does not match %s", id, client.ClientName, pattern))
+			}
+		})
+	}, nil
+}
+
+// forEachClient calls fn for every client of the workspace, sorted by id
+func forEachClient(server *models.TreeServer, fn func(id string, client models.TreeClient)) {
+	var ids = make([]string, 0, len(server.Clients))
+
+	for id := range server.Clients {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		fn(id, server.Clients[id])
+	}
+}
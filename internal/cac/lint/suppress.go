@@ -0,0 +1,142 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/provenance"
+	"golang.org/x/exp/slog"
+)
+
+const (
+	// DisableComment suppresses rules for the value on the same line or on the next line, and for values nested in it
+	DisableComment = "cac-lint-disable"
+	// DisableFileComment suppresses rules for the whole file
+	DisableFileComment = "cac-lint-disable-file"
+	// DisableMetadata is a client metadata field with rules suppressed for the client
+	DisableMetadata = "cac_lint_disable"
+)
+
+type suppressor struct {
+	positions provenance.Map
+	files     map[string][]string
+}
+
+func newSuppressor(positions provenance.Map) *suppressor {
+	return &suppressor{
+		positions: positions,
+		files:     map[string][]string{},
+	}
+}
+
+// suppressed returns true if the rule is disabled for the value by client metadata or a comment in the source file
+// the pointer is relative to the server, which configuration starts at the prefix
+func (s *suppressor) suppressed(rule string, server *models.TreeServer, prefix string, pointer string) bool {
+	var segments = strings.Split(pointer, "/")
+
+	if len(segments) > 2 && segments[1] == "clients" {
+		if client, ok := server.Clients[unescape(segments[2])]; ok && disables(client.Metadata[DisableMetadata], rule) {
+			return true
+		}
+	}
+
+	return s.suppressedByComment(rule, prefix+pointer)
+}
+
+func (s *suppressor) suppressedByComment(rule string, pointer string) bool {
+	for p := pointer; p != ""; p = p[:max(strings.LastIndex(p, "/"), 0)] {
+		position, ok := s.positions[p]
+
+		if !ok {
+			continue
+		}
+
+		lines := s.lines(position.File)
+
+		for _, line := range lines {
+			if directive, ok := commentDirective(line, DisableFileComment); ok && disables(directive, rule) {
+				return true
+			}
+		}
+
+		for _, n := range []int{position.Line, position.Line - 1} {
+			if n < 1 || n > len(lines) {
+				continue
+			}
+
+			if directive, ok := commentDirective(lines[n-1], DisableComment); ok && disables(directive, rule) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (s *suppressor) lines(file string) []string {
+	if lines, ok := s.files[file]; ok {
+		return lines
+	}
+
+	bts, err := os.ReadFile(file)
+
+	if err != nil {
+		slog.Debug("failed to read file to check lint suppressions", "file", file, "error", err)
+	}
+
+	s.files[file] = strings.Split(string(bts), "\n")
+
+	return s.files[file]
+}
+
+// commentDirective returns rules listed after the directive in a YAML comment of the line
+func commentDirective(line string, directive string) (string, bool) {
+	_, comment, found := strings.Cut(line, "#")
+
+	if !found {
+		return "", false
+	}
+
+	fields := strings.Fields(comment)
+
+	if len(fields) == 0 || fields[0] != directive {
+		return "", false
+	}
+
+	// directive without rules disables all of them
+	if len(fields) == 1 {
+		return "all", true
+	}
+
+	return strings.Join(fields[1:], ","), true
+}
+
+// disables returns true if the rule or "all" is listed in the comma separated string or list
+func disables(value any, rule string) bool {
+	var rules []string
+
+	switch v := value.(type) {
+	case string:
+		rules = strings.Split(v, ",")
+	case []any:
+		for _, r := range v {
+			rules = append(rules, fmt.Sprint(r))
+		}
+	}
+
+	for _, r := range rules {
+		if r = strings.TrimSpace(r); r == rule || r == "all" {
+			return true
+		}
+	}
+
+	return false
+}
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+func unescape(segment string) string {
+	return pointerUnescaper.Replace(segment)
+}
@@ -0,0 +1,201 @@
+package plan
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"time"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/cloudentity/cac/internal/cac/diff"
+	"github.com/cloudentity/cac/internal/cac/utils"
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slog"
+)
+
+const Version = 1
+
+var ErrStalePlan = errors.New("remote configuration has changed since the plan was created")
+
+// volatile fields change without any user action, so they are not part of the fingerprint
+var volatileFields = []string{
+	"updated_at",
+	"last_active",
+}
+
+type Plan struct {
+	Version     int                          `json:"version"`
+	CreatedAt   time.Time                    `json:"created_at"`
+	Workspace   string                       `json:"workspace,omitempty"`
+	Tenant      bool                         `json:"tenant,omitempty"`
+	Filters     []string                     `json:"filters,omitempty"`
+	Fingerprint string                       `json:"fingerprint"`
+	Patch       models.Rfc7396PatchOperation `json:"patch"`
+}
+
+// Create computes the patch which applied to the remote makes it match the local configuration
+// local configuration is expected to be already read and validated
+func Create(ctx context.Context, local models.Rfc7396PatchOperation, remote api.Source, opts ...api.SourceOpt) (*Plan, error) {
+	var (
+		options     = &api.Options{}
+		remoteData  models.Rfc7396PatchOperation
+		fingerprint string
+		patch       models.Rfc7396PatchOperation
+		err         error
+	)
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	// secrets stored locally are compared with the remote ones, so the remote is read with secrets
+	if remoteData, err = remote.Read(ctx, append(opts, api.WithSecrets(true))...); err != nil {
+		return nil, errors.Wrap(err, "failed to read remote configuration")
+	}
+
+	if fingerprint, err = Fingerprint(remoteData); err != nil {
+		return nil, err
+	}
+
+	if patch, err = diff.Patch(local, remoteData, diff.OnlyPresent(true)); err != nil {
+		return nil, errors.Wrap(err, "failed to compute patch")
+	}
+
+	return &Plan{
+		Version:     Version,
+		CreatedAt:   time.Now().UTC(),
+		Workspace:   options.Workspace,
+		Filters:     options.Filters,
+		Fingerprint: fingerprint,
+		Patch:       patch,
+	}, nil
+}
+
+// Apply writes the planned patch to the remote if the remote did not change since the plan was created
+func (p *Plan) Apply(ctx context.Context, remote api.Source, mode string) error {
+	var (
+		remoteData  models.Rfc7396PatchOperation
+		fingerprint string
+		err         error
+	)
+
+	if remoteData, err = remote.Read(ctx, p.readOpts()...); err != nil {
+		return errors.Wrap(err, "failed to read remote configuration")
+	}
+
+	if fingerprint, err = Fingerprint(remoteData); err != nil {
+		return err
+	}
+
+	if fingerprint != p.Fingerprint {
+		slog.Debug("fingerprint mismatch", "expected", p.Fingerprint, "actual", fingerprint)
+		return ErrStalePlan
+	}
+
+	if p.Empty() {
+		slog.Info("plan has no changes, nothing to apply")
+		return nil
+	}
+
+	return remote.Write(ctx, p.Patch,
+		api.WithWorkspace(p.Workspace),
+		api.WithMode(mode),
+		api.WithMethod("patch"),
+	)
+}
+
+func (p *Plan) Empty() bool {
+	return len(p.Patch) == 0
+}
+
+func (p *Plan) readOpts() []api.SourceOpt {
+	var opts = []api.SourceOpt{
+		api.WithFilters(p.Filters),
+		api.WithSecrets(true),
+	}
+
+	if p.Workspace != "" {
+		opts = append(opts, api.WithWorkspace(p.Workspace))
+	}
+
+	return opts
+}
+
+// Fingerprint returns sha256 of the normalized configuration with volatile fields removed
+func Fingerprint(data models.Rfc7396PatchOperation) (string, error) {
+	var (
+		normalized models.Rfc7396PatchOperation
+		bts        []byte
+		err        error
+	)
+
+	if normalized, err = utils.NormalizePatch(data); err != nil {
+		return "", err
+	}
+
+	utils.CleanPatch(normalized)
+	removeFields(normalized, volatileFields)
+
+	if bts, err = json.Marshal(normalized, json.Deterministic(true)); err != nil {
+		return "", errors.Wrap(err, "failed to marshal configuration")
+	}
+
+	sum := sha256.Sum256(bts)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func removeFields(data map[string]any, fields []string) {
+	for _, f := range fields {
+		delete(data, f)
+	}
+
+	for _, v := range data {
+		if m, ok := v.(map[string]any); ok {
+			removeFields(m, fields)
+		}
+	}
+}
+
+func Write(path string, p *Plan) error {
+	var (
+		bts []byte
+		err error
+	)
+
+	if bts, err = json.Marshal(p, json.Deterministic(true), jsontext.WithIndent("  ")); err != nil {
+		return errors.Wrap(err, "failed to marshal plan")
+	}
+
+	if err = os.WriteFile(path, bts, 0644); err != nil {
+		return errors.Wrap(err, "failed to write plan")
+	}
+
+	return nil
+}
+
+func Read(path string) (*Plan, error) {
+	var (
+		p   = &Plan{}
+		bts []byte
+		err error
+	)
+
+	if bts, err = os.ReadFile(path); err != nil {
+		return nil, errors.Wrap(err, "failed to read plan")
+	}
+
+	if err = json.Unmarshal(bts, p); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal plan")
+	}
+
+	if p.Version != Version {
+		return nil, errors.Errorf("unsupported plan version: %d", p.Version)
+	}
+
+	return p, nil
+}
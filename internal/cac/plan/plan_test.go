@@ -0,0 +1,145 @@
+package plan_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/cloudentity/cac/internal/cac/plan"
+	"github.com/stretchr/testify/require"
+)
+
+type memorySource struct {
+	data    models.Rfc7396PatchOperation
+	secrets models.Rfc7396PatchOperation // returned only when secrets are requested
+	written []models.Rfc7396PatchOperation
+}
+
+func (m *memorySource) Read(ctx context.Context, opts ...api.SourceOpt) (models.Rfc7396PatchOperation, error) {
+	var (
+		out     = models.Rfc7396PatchOperation{}
+		options = &api.Options{}
+	)
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	for k, v := range m.data {
+		out[k] = v
+	}
+
+	if options.Secrets {
+		for k, v := range m.secrets {
+			out[k] = v
+		}
+	}
+
+	return out, nil
+}
+
+func (m *memorySource) Write(ctx context.Context, data models.Rfc7396PatchOperation, opts ...api.SourceOpt) error {
+	m.written = append(m.written, data)
+	return nil
+}
+
+func (m *memorySource) String() string {
+	return "memory"
+}
+
+func TestPlan(t *testing.T) {
+	local := models.Rfc7396PatchOperation{
+		"name": "demo",
+		"clients": map[string]any{
+			"c1": map[string]any{"client_name": "client1", "scopes": []any{"openid", "email"}},
+		},
+	}
+
+	t.Run("plan contains only changed fields", func(t *testing.T) {
+		remote := &memorySource{data: models.Rfc7396PatchOperation{
+			"name": "demo",
+			"clients": map[string]any{
+				"c1": map[string]any{"client_name": "client1", "scopes": []any{"openid"}, "updated_at": "2024-01-01"},
+				"c2": map[string]any{"client_name": "client2"},
+			},
+		}}
+
+		p, err := plan.Create(context.Background(), local, remote, api.WithWorkspace("demo"))
+		require.NoError(t, err)
+		require.Equal(t, "demo", p.Workspace)
+		require.Equal(t, models.Rfc7396PatchOperation{
+			"clients": map[string]any{
+				"c1": map[string]any{"scopes": []any{"openid", "email"}},
+			},
+		}, p.Patch)
+	})
+
+	t.Run("plan is saved and applied when remote did not change", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cac.plan")
+		remote := &memorySource{data: models.Rfc7396PatchOperation{"name": "old"}}
+
+		p, err := plan.Create(context.Background(), local, remote, api.WithWorkspace("demo"))
+		require.NoError(t, err)
+		require.NoError(t, plan.Write(path, p))
+
+		p, err = plan.Read(path)
+		require.NoError(t, err)
+
+		require.NoError(t, p.Apply(context.Background(), remote, "update"))
+		require.Len(t, remote.written, 1)
+		require.Equal(t, "demo", remote.written[0]["name"])
+	})
+
+	t.Run("unchanged secret is not planned", func(t *testing.T) {
+		local := models.Rfc7396PatchOperation{
+			"name":    "demo",
+			"clients": map[string]any{"c1": map[string]any{"client_name": "client1", "client_secret": "secret"}},
+		}
+		remote := &memorySource{
+			data: models.Rfc7396PatchOperation{
+				"name":    "demo",
+				"clients": map[string]any{"c1": map[string]any{"client_name": "client1"}},
+			},
+			secrets: models.Rfc7396PatchOperation{
+				"clients": map[string]any{"c1": map[string]any{"client_name": "client1", "client_secret": "secret"}},
+			},
+		}
+
+		p, err := plan.Create(context.Background(), local, remote, api.WithWorkspace("demo"))
+		require.NoError(t, err)
+		require.True(t, p.Empty())
+
+		remote.secrets["clients"] = map[string]any{"c1": map[string]any{"client_name": "client1", "client_secret": "rotated"}}
+
+		err = p.Apply(context.Background(), remote, "update")
+		require.ErrorIs(t, err, plan.ErrStalePlan)
+	})
+
+	t.Run("apply fails when remote changed since planning", func(t *testing.T) {
+		remote := &memorySource{data: models.Rfc7396PatchOperation{"name": "old"}}
+
+		p, err := plan.Create(context.Background(), local, remote, api.WithWorkspace("demo"))
+		require.NoError(t, err)
+
+		remote.data["name"] = "changed"
+
+		err = p.Apply(context.Background(), remote, "update")
+		require.ErrorIs(t, err, plan.ErrStalePlan)
+		require.Empty(t, remote.written)
+	})
+
+	t.Run("volatile fields do not change fingerprint", func(t *testing.T) {
+		f1, err := plan.Fingerprint(models.Rfc7396PatchOperation{
+			"clients": map[string]any{"c1": map[string]any{"client_name": "c", "last_active": "2024-01-01"}},
+		})
+		require.NoError(t, err)
+
+		f2, err := plan.Fingerprint(models.Rfc7396PatchOperation{
+			"clients": map[string]any{"c1": map[string]any{"client_name": "c", "last_active": "2024-02-01"}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, f1, f2)
+	})
+}
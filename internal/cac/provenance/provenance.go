@@ -0,0 +1,196 @@
+package provenance
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/go-json-experiment/json"
+	oaerrors "github.com/go-openapi/errors"
+)
+
+// Position is a location of a value in a source file
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// Map maps JSON pointers (RFC 6901) of configuration values to positions in source files
+type Map map[string]Position
+
+// Merge copies positions of other map under the prefix, overriding existing ones
+func (m Map) Merge(prefix string, other Map) {
+	for pointer, position := range other {
+		m[prefix+pointer] = position
+	}
+}
+
+// Lookup returns the position of the value or of its closest ancestor which position is known
+func (m Map) Lookup(pointer string) (Position, bool) {
+	for {
+		if position, ok := m[pointer]; ok {
+			return position, true
+		}
+
+		idx := strings.LastIndex(pointer, "/")
+
+		if idx < 0 {
+			return Position{}, false
+		}
+
+		pointer = pointer[:idx]
+	}
+}
+
+// PointerError is implemented by errors of a configuration value identified by a JSON pointer
+type PointerError interface {
+	error
+	Pointer() string
+}
+
+// ContentError is implemented by errors located inside a multiline string value, e.g. a syntax error of a script body
+type ContentError interface {
+	PointerError
+	// ContentPosition returns the line and the column in the value, starting at 1
+	ContentPosition() (line int, column int)
+}
+
+// Error is an error of a configuration value with its position in a source file
+type Error struct {
+	Position Position
+	Err      error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Position, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Locate maps errors pointing at configuration values to their positions in source files
+// joined and composite errors are located one by one, errors which can't be located are returned as they are
+func (m Map) Locate(err error) error {
+	var (
+		composite *oaerrors.CompositeError
+		errs      []error
+	)
+
+	if err == nil || len(m) == 0 {
+		return err
+	}
+
+	if joined, isJoined := err.(interface{ Unwrap() []error }); isJoined {
+		errs = joined.Unwrap()
+	} else if errors.As(err, &composite) {
+		errs = composite.Errors
+	}
+
+	if len(errs) > 0 {
+		located := make([]error, 0, len(errs))
+
+		for _, e := range errs {
+			located = append(located, m.Locate(e))
+		}
+
+		return errors.Join(located...)
+	}
+
+	if position, found := m.PositionOf(err); found {
+		return &Error{Position: position, Err: err}
+	}
+
+	return err
+}
+
+// PositionOf returns the position of the value the error refers to
+// errors inside a value included from a separate file, e.g. an extracted script body, point to the included file
+func (m Map) PositionOf(err error) (Position, bool) {
+	var (
+		ce      ContentError
+		pointer string
+		ok      bool
+	)
+
+	if errors.As(err, &ce) {
+		if position, found := m[ce.Pointer()]; found {
+			line, column := ce.ContentPosition()
+			return contentPosition(position, line, column), true
+		}
+	}
+
+	if pointer, ok = PointerOf(err); !ok {
+		return Position{}, false
+	}
+
+	return m.Lookup(pointer)
+}
+
+var includeRegexp = regexp.MustCompile(`include\s+"([^"]+)"`)
+
+// contentPosition returns the position of the line of a multiline value which starts at the line following its key
+// values written with the include template function are located in the included file
+func contentPosition(key Position, line int, column int) Position {
+	if bts, err := os.ReadFile(key.File); err == nil {
+		if lines := strings.Split(string(bts), "\n"); key.Line <= len(lines) {
+			if match := includeRegexp.FindStringSubmatch(lines[key.Line-1]); match != nil {
+				file := filepath.Join(filepath.Dir(key.File), match[1])
+
+				// same as the include function, absolute paths are relative to the working directory
+				if strings.HasPrefix(match[1], "/") {
+					file = match[1][1:]
+				}
+
+				return Position{File: file, Line: line, Column: column}
+			}
+		}
+	}
+
+	return Position{File: key.File, Line: key.Line + line, Column: column}
+}
+
+// PointerOf returns the JSON pointer of the configuration value the error refers to
+func PointerOf(err error) (string, bool) {
+	var (
+		pe  PointerError
+		se  *json.SemanticError
+		val *oaerrors.Validation
+	)
+
+	switch {
+	case errors.As(err, &pe):
+		return pe.Pointer(), true
+	case errors.As(err, &se) && se.JSONPointer != "":
+		return string(se.JSONPointer), true
+	case errors.As(err, &val) && val.Name != "":
+		// generated validation uses dot separated names, e.g. clients.c1.grant_types.0
+		return "/" + strings.ReplaceAll(val.Name, ".", "/"), true
+	}
+
+	return "", false
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// Join appends escaped segments to the JSON pointer
+func Join(pointer string, segments ...string) string {
+	var sb strings.Builder
+
+	sb.WriteString(pointer)
+
+	for _, s := range segments {
+		sb.WriteString("/")
+		sb.WriteString(pointerEscaper.Replace(s))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,58 @@
+package provenance_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cloudentity/cac/internal/cac/provenance"
+	"github.com/stretchr/testify/require"
+)
+
+type pointerError string
+
+func (e pointerError) Error() string   { return "invalid value" }
+func (e pointerError) Pointer() string { return string(e) }
+
+func TestFromYAML(t *testing.T) {
+	positions, err := provenance.FromYAML("clients/demo.yaml", []byte(`id: c1
+client_name: demo
+redirect_uris:
+  - https://example.com
+metadata:
+  "a/b": c
+`))
+	require.NoError(t, err)
+
+	require.Equal(t, provenance.Position{File: "clients/demo.yaml", Line: 2, Column: 1}, positions["/client_name"])
+	require.Equal(t, provenance.Position{File: "clients/demo.yaml", Line: 4, Column: 5}, positions["/redirect_uris/0"])
+	require.Equal(t, provenance.Position{File: "clients/demo.yaml", Line: 6, Column: 3}, positions["/metadata/a~1b"])
+}
+
+func TestLocate(t *testing.T) {
+	positions := provenance.Map{}
+	positions.Merge("/clients/c1", provenance.Map{
+		"":            {File: "clients/demo.yaml", Line: 1, Column: 1},
+		"/scopes":     {File: "clients/demo.yaml", Line: 3, Column: 1},
+		"/scopes/0":   {File: "clients/demo.yaml", Line: 4, Column: 5},
+		"/grant_type": {File: "clients/demo.yaml", Line: 5, Column: 1},
+	})
+
+	t.Run("closest ancestor", func(t *testing.T) {
+		position, ok := positions.Lookup("/clients/c1/scopes/1")
+		require.True(t, ok)
+		require.Equal(t, "clients/demo.yaml:3:1", position.String())
+
+		_, ok = positions.Lookup("/idps/i1")
+		require.False(t, ok)
+	})
+
+	t.Run("joined errors", func(t *testing.T) {
+		err := positions.Locate(errors.Join(
+			pointerError("/clients/c1/scopes/0"),
+			pointerError("/idps/i1"),
+			errors.New("other"),
+		))
+
+		require.EqualError(t, err, "clients/demo.yaml:4:5: invalid value\ninvalid value\nother")
+	})
+}
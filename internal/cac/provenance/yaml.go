@@ -0,0 +1,67 @@
+package provenance
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// FromYAML returns positions of all values of the YAML document, pointers are relative to the document root
+func FromYAML(file string, bts []byte) (Map, error) {
+	var (
+		out = Map{}
+		f   *ast.File
+		err error
+	)
+
+	if f, err = parser.ParseBytes(bts, 0); err != nil {
+		return out, err
+	}
+
+	for _, doc := range f.Docs {
+		walk(out, file, "", doc.Body)
+	}
+
+	return out, nil
+}
+
+func walk(out Map, file string, pointer string, node ast.Node) {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		for _, v := range n.Values {
+			walk(out, file, pointer, v)
+		}
+	case *ast.MappingValueNode:
+		var (
+			token = n.Key.GetToken()
+			p     = Join(pointer, keyString(n.Key))
+		)
+
+		out[p] = Position{File: file, Line: token.Position.Line, Column: token.Position.Column}
+		walk(out, file, p, n.Value)
+	case *ast.SequenceNode:
+		for i, v := range n.Values {
+			var (
+				p     = Join(pointer, strconv.Itoa(i))
+				token = v.GetToken()
+			)
+
+			out[p] = Position{File: file, Line: token.Position.Line, Column: token.Position.Column}
+			walk(out, file, p, v)
+		}
+	case *ast.AnchorNode:
+		walk(out, file, pointer, n.Value)
+	case *ast.TagNode:
+		walk(out, file, pointer, n.Value)
+	}
+}
+
+func keyString(key ast.MapKeyNode) string {
+	if scalar, ok := key.(ast.ScalarNode); ok {
+		return fmt.Sprint(scalar.GetValue())
+	}
+
+	return key.String()
+}
@@ -0,0 +1,79 @@
+package rego
+
+import (
+	"context"
+
+	"github.com/go-json-experiment/json"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/pkg/errors"
+)
+
+type evalOptions struct {
+	modules map[string]string
+	paths   []string
+}
+
+type EvalOpt func(*evalOptions)
+
+// WithModule adds the Rego module source, the file name is used in error messages
+func WithModule(file string, source string) EvalOpt {
+	return func(o *evalOptions) {
+		o.modules[file] = source
+	}
+}
+
+// WithPaths loads Rego modules and data files from the paths, like the opa --data flag
+func WithPaths(paths ...string) EvalOpt {
+	return func(o *evalOptions) {
+		o.paths = append(o.paths, paths...)
+	}
+}
+
+// Eval evaluates the query against the input with the embedded Rego engine, so no opa executable is needed
+// values of the query expressions are returned, an undefined query returns no values
+func Eval(ctx context.Context, query string, input any, opts ...EvalOpt) ([]any, error) {
+	var (
+		options  = &evalOptions{modules: map[string]string{}}
+		regoOpts []func(*rego.Rego)
+		rs       rego.ResultSet
+		bts      []byte
+		value    any
+		out      []any
+		err      error
+	)
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	// the input is passed as plain JSON values, so named types such as patch operations are handled too
+	if bts, err = json.Marshal(input, json.Deterministic(true)); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal input")
+	}
+
+	if err = json.Unmarshal(bts, &value); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal input")
+	}
+
+	regoOpts = append(regoOpts, rego.Query(query), rego.Input(value))
+
+	for file, source := range options.modules {
+		regoOpts = append(regoOpts, rego.Module(file, source))
+	}
+
+	if len(options.paths) > 0 {
+		regoOpts = append(regoOpts, rego.Load(options.paths, nil))
+	}
+
+	if rs, err = rego.New(regoOpts...).Eval(ctx); err != nil {
+		return nil, err
+	}
+
+	for _, r := range rs {
+		for _, e := range r.Expressions {
+			out = append(out, e.Value)
+		}
+	}
+
+	return out, nil
+}
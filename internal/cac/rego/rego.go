@@ -0,0 +1,221 @@
+package rego
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/go-json-experiment/json"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slog"
+)
+
+type Configuration struct {
+	// DirPath is a directory with Rego modules evaluated against the configuration, missing directory disables the rules
+	DirPath string `json:"dir_path"`
+}
+
+var DefaultConfig = func() *Configuration {
+	return &Configuration{
+		DirPath: "rules",
+	}
+}
+
+// ResolvePaths makes a relative rules directory relative to dir, so rules are found independently of the working directory
+func (c *Configuration) ResolvePaths(dir string) {
+	if c == nil || c.DirPath == "" || filepath.IsAbs(c.DirPath) {
+		return
+	}
+
+	c.DirPath = filepath.Join(dir, c.DirPath)
+}
+
+// Violation is a deny result of a Rego rule
+// rules may deny with a string or with an object with msg and an optional JSON pointer of the offending value
+type Violation struct {
+	Package string `json:"-"`
+	Msg     string `json:"msg"`
+	Path    string `json:"pointer"`
+}
+
+func (v Violation) Error() string {
+	return fmt.Sprintf("%s: %s", v.Package, v.Msg)
+}
+
+func (v Violation) Pointer() string {
+	return v.Path
+}
+
+func (v *Violation) UnmarshalJSON(bts []byte) error {
+	var (
+		msg string
+		obj struct {
+			Msg     string `json:"msg"`
+			Pointer string `json:"pointer"`
+		}
+	)
+
+	if json.Unmarshal(bts, &msg) == nil {
+		v.Msg = msg
+		return nil
+	}
+
+	if err := json.Unmarshal(bts, &obj); err != nil {
+		return errors.Wrap(err, "deny result must be a string or an object with msg")
+	}
+
+	v.Msg, v.Path = obj.Msg, obj.Pointer
+
+	return nil
+}
+
+type RuleError struct {
+	Violations []Violation
+}
+
+func (e *RuleError) Error() string {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "configuration denied by %d rules:", len(e.Violations))
+
+	for _, v := range e.Violations {
+		fmt.Fprintf(&buf, "\n%s", v.Error())
+	}
+
+	return buf.String()
+}
+
+func (e *RuleError) Unwrap() []error {
+	var errs = make([]error, 0, len(e.Violations))
+
+	for _, v := range e.Violations {
+		errs = append(errs, v)
+	}
+
+	return errs
+}
+
+// Validator evaluates deny rules of all Rego packages in the rules directory against the configuration
+type Validator struct {
+	Config *Configuration
+}
+
+var packageRegexp = regexp.MustCompile(`(?m)^\s*package\s+([\w.]+)`)
+
+func (rv *Validator) Validate(data *models.Rfc7396PatchOperation) error {
+	var (
+		packages   []string
+		violations []Violation
+		err        error
+	)
+
+	if packages, err = rv.packages(); err != nil {
+		return err
+	}
+
+	if len(packages) == 0 {
+		return nil
+	}
+
+	for _, pkg := range packages {
+		var denied []Violation
+
+		if denied, err = rv.eval(pkg, data); err != nil {
+			return err
+		}
+
+		violations = append(violations, denied...)
+	}
+
+	if len(violations) > 0 {
+		return &RuleError{Violations: violations}
+	}
+
+	return nil
+}
+
+// packages returns names of packages declared by modules in the rules directory
+func (rv *Validator) packages() ([]string, error) {
+	var (
+		seen = map[string]bool{}
+		out  []string
+	)
+
+	if rv.Config == nil || rv.Config.DirPath == "" {
+		return nil, nil
+	}
+
+	if _, err := os.Stat(rv.Config.DirPath); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	err := filepath.WalkDir(rv.Config.DirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".rego" {
+			return err
+		}
+
+		bts, err := os.ReadFile(path)
+
+		if err != nil {
+			return errors.Wrapf(err, "failed to read rule %s", path)
+		}
+
+		match := packageRegexp.FindSubmatch(bts)
+
+		if match == nil {
+			return errors.Errorf("rule %s has no package declaration", path)
+		}
+
+		if pkg := string(match[1]); !seen[pkg] {
+			seen[pkg] = true
+			out = append(out, pkg)
+		}
+
+		return nil
+	})
+
+	sort.Strings(out)
+
+	return out, err
+}
+
+func (rv *Validator) eval(pkg string, data *models.Rfc7396PatchOperation) ([]Violation, error) {
+	var (
+		values []any
+		bts    []byte
+		out    []Violation
+		err    error
+	)
+
+	slog.Debug("evaluating rego rules", "package", pkg)
+
+	if values, err = Eval(context.Background(), "data."+pkg+".deny", data, WithPaths(rv.Config.DirPath)); err != nil {
+		return nil, errors.Wrapf(err, "failed to evaluate rules of package %s", pkg)
+	}
+
+	// undefined deny results in no values
+	for _, value := range values {
+		var denied []Violation
+
+		if bts, err = json.Marshal(value); err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal result of rules of package %s", pkg)
+		}
+
+		if err = json.Unmarshal(bts, &denied); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse result of rules of package %s", pkg)
+		}
+
+		for _, v := range denied {
+			v.Package = pkg
+			out = append(out, v)
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,68 @@
+package rego_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/rego"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidator(t *testing.T) {
+	var (
+		dir   = t.TempDir()
+		rules = filepath.Join(dir, "rules")
+		data  = models.Rfc7396PatchOperation{"id": "w", "clients": map[string]any{"c1": map[string]any{"grant_types": []any{"implicit"}}}}
+	)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(rules, "nested"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(rules, "clients.rego"), []byte(`package cac.clients
+
+import rego.v1
+
+deny contains sprintf("client %s has no name", [id]) if {
+	some id, client in input.clients
+	not client.client_name
+}
+
+deny contains {"msg": "implicit grant", "pointer": sprintf("/clients/%s/grant_types/%d", [id, i])} if {
+	some id, client in input.clients
+	some i, grant in client.grant_types
+	grant == "implicit"
+}
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(rules, "nested", "other.rego"), []byte("# other rules\npackage cac.other\n\nallow := true\n"), 0600))
+
+	t.Run("violations", func(t *testing.T) {
+		var (
+			v   = &rego.Validator{Config: &rego.Configuration{DirPath: rules}}
+			re  *rego.RuleError
+			err = v.Validate(&data)
+		)
+
+		require.True(t, errors.As(err, &re))
+		require.Equal(t, []rego.Violation{
+			{Package: "cac.clients", Msg: "client c1 has no name"},
+			{Package: "cac.clients", Msg: "implicit grant", Path: "/clients/c1/grant_types/0"},
+		}, re.Violations)
+		require.Equal(t, "/clients/c1/grant_types/0", re.Violations[1].Pointer())
+	})
+
+	t.Run("missing rules directory", func(t *testing.T) {
+		v := &rego.Validator{Config: &rego.Configuration{DirPath: filepath.Join(dir, "missing")}}
+		require.NoError(t, v.Validate(&data))
+	})
+
+	t.Run("invalid rules", func(t *testing.T) {
+		invalid := filepath.Join(dir, "invalid")
+
+		require.NoError(t, os.MkdirAll(invalid, 0700))
+		require.NoError(t, os.WriteFile(filepath.Join(invalid, "broken.rego"), []byte("package cac.broken\n\ndeny[msg] {\n"), 0600))
+
+		v := &rego.Validator{Config: &rego.Configuration{DirPath: invalid}}
+		require.ErrorContains(t, v.Validate(&data), "failed to evaluate rules of package cac.broken")
+	})
+}
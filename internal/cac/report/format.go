@@ -0,0 +1,153 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+	"github.com/pkg/errors"
+)
+
+type Format string
+
+const (
+	FormatText  Format = "text"
+	FormatJSON  Format = "json"
+	FormatJUnit Format = "junit"
+)
+
+var ErrUnknownFormat = errors.New("unknown report format, supported formats: text, json, junit")
+
+func FormatFromString(format string) (Format, error) {
+	switch Format(format) {
+	case FormatText, FormatJSON, FormatJUnit:
+		return Format(format), nil
+	}
+
+	return "", ErrUnknownFormat
+}
+
+// Write renders the report in the given format, name is used as the JUnit test suite name
+func (r *Report) Write(w io.Writer, format Format, name string) error {
+	switch format {
+	case FormatText:
+		return r.writeText(w)
+	case FormatJSON:
+		return r.writeJSON(w)
+	case FormatJUnit:
+		return r.writeJUnit(w, name)
+	}
+
+	return ErrUnknownFormat
+}
+
+func (r *Report) writeText(w io.Writer) error {
+	for _, i := range r.Issues {
+		if _, err := fmt.Fprintln(w, i.String()); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d errors, %d warnings\n", r.Count(SeverityError), r.Count(SeverityWarning))
+
+	return err
+}
+
+func (r *Report) writeJSON(w io.Writer) error {
+	var (
+		out = struct {
+			Issues   []Issue `json:"issues"`
+			Errors   int     `json:"errors"`
+			Warnings int     `json:"warnings"`
+		}{
+			Issues:   r.Issues,
+			Errors:   r.Count(SeverityError),
+			Warnings: r.Count(SeverityWarning),
+		}
+		bts []byte
+		err error
+	)
+
+	if out.Issues == nil {
+		out.Issues = []Issue{}
+	}
+
+	if bts, err = json.Marshal(out, json.Deterministic(true), jsontext.WithIndent("  ")); err != nil {
+		return errors.Wrap(err, "failed to marshal report")
+	}
+
+	_, err = w.Write(append(bts, '\n'))
+
+	return err
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit reports every issue as a failed test case, so CI systems can annotate files
+// a passing test case is reported when there are no issues, as some systems reject empty suites
+func (r *Report) writeJUnit(w io.Writer, name string) error {
+	var (
+		suite = junitTestSuite{Name: name}
+		bts   []byte
+		err   error
+	)
+
+	for _, i := range r.Issues {
+		suite.Cases = append(suite.Cases, junitTestCase{
+			Name:      fmt.Sprintf("%s: %s", i.Rule, i.Message),
+			Classname: i.Location(),
+			File:      i.File,
+			Line:      i.Line,
+			Failure: &junitFailure{
+				Message: i.Message,
+				Type:    string(i.Severity),
+				Text:    i.String(),
+			},
+		})
+	}
+
+	suite.Tests, suite.Failures = len(suite.Cases), len(suite.Cases)
+
+	if len(suite.Cases) == 0 {
+		suite.Cases = []junitTestCase{{Name: "configuration", Classname: name}}
+		suite.Tests = 1
+	}
+
+	if bts, err = xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{suite}}, "", "  "); err != nil {
+		return errors.Wrap(err, "failed to marshal report")
+	}
+
+	if _, err = io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(bts, '\n'))
+
+	return err
+}
@@ -0,0 +1,150 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cloudentity/cac/internal/cac/provenance"
+	oaerrors "github.com/go-openapi/errors"
+	"github.com/pkg/errors"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// RuleValidation is the rule of issues reported by the API model and reference validators
+const RuleValidation = "validation"
+
+// Issue is a single problem found in the local configuration
+type Issue struct {
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Pointer  string   `json:"pointer,omitempty"`
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// Location returns the position of the issue in the source file, or the JSON pointer if the position is unknown
+func (i Issue) Location() string {
+	switch {
+	case i.File != "" && i.Line > 0:
+		return fmt.Sprintf("%s:%d:%d", i.File, i.Line, i.Column)
+	case i.File != "":
+		return i.File
+	}
+
+	return i.Pointer
+}
+
+func (i Issue) String() string {
+	if location := i.Location(); location != "" {
+		return fmt.Sprintf("%s: %s: %s (%s)", location, i.Severity, i.Message, i.Rule)
+	}
+
+	return fmt.Sprintf("%s: %s (%s)", i.Severity, i.Message, i.Rule)
+}
+
+// Report collects issues of the configuration
+type Report struct {
+	Issues []Issue `json:"issues"`
+}
+
+// Add appends the issue, locating it in source files if it has a JSON pointer and no file set
+func (r *Report) Add(issue Issue, positions provenance.Map) {
+	if issue.File == "" && issue.Pointer != "" {
+		if position, ok := positions.Lookup(issue.Pointer); ok {
+			issue.File, issue.Line, issue.Column = position.File, position.Line, position.Column
+		}
+	}
+
+	r.Issues = append(r.Issues, issue)
+}
+
+// AddError splits joined and composite errors into separate issues
+func (r *Report) AddError(rule string, severity Severity, err error, positions provenance.Map) {
+	for _, e := range Flatten(err) {
+		var (
+			pointer, _      = provenance.PointerOf(e)
+			position, found = positions.PositionOf(e)
+			issue           = Issue{
+				Pointer:  pointer,
+				Rule:     rule,
+				Severity: severity,
+				Message:  e.Error(),
+			}
+		)
+
+		if found {
+			issue.File, issue.Line, issue.Column = position.File, position.Line, position.Column
+		}
+
+		r.Issues = append(r.Issues, issue)
+	}
+}
+
+// Count returns the number of issues with the given severity
+func (r *Report) Count(severity Severity) int {
+	var count int
+
+	for _, i := range r.Issues {
+		if i.Severity == severity {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Sort orders issues by their location
+func (r *Report) Sort() {
+	sort.SliceStable(r.Issues, func(a, b int) bool {
+		var x, y = r.Issues[a], r.Issues[b]
+
+		if x.File != y.File {
+			return x.File < y.File
+		}
+
+		if x.Line != y.Line {
+			return x.Line < y.Line
+		}
+
+		if x.Column != y.Column {
+			return x.Column < y.Column
+		}
+
+		return x.Pointer < y.Pointer
+	})
+}
+
+// Flatten returns leaf errors of joined and composite errors
+func Flatten(err error) []error {
+	var (
+		composite *oaerrors.CompositeError
+		errs      []error
+		out       []error
+	)
+
+	if err == nil {
+		return nil
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	} else if errors.As(err, &composite) {
+		errs = composite.Errors
+	} else {
+		return []error{err}
+	}
+
+	for _, e := range errs {
+		out = append(out, Flatten(e)...)
+	}
+
+	return out
+}
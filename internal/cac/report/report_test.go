@@ -0,0 +1,98 @@
+package report_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/cloudentity/cac/internal/cac/provenance"
+	"github.com/cloudentity/cac/internal/cac/report"
+	oaerrors "github.com/go-openapi/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type pointerError string
+
+func (e pointerError) Error() string   { return "invalid value" }
+func (e pointerError) Pointer() string { return string(e) }
+
+func sampleReport() *report.Report {
+	var (
+		rep       = &report.Report{}
+		positions = provenance.Map{
+			"/clients/c1":        {File: "clients/demo.yaml", Line: 1, Column: 1},
+			"/clients/c1/scopes": {File: "clients/demo.yaml", Line: 3, Column: 1},
+		}
+	)
+
+	rep.AddError(report.RuleValidation, report.SeverityError, errors.Join(
+		pointerError("/clients/c1/scopes/0"),
+		oaerrors.CompositeValidationError(
+			oaerrors.Required("clients.c1.client_name", "body", nil),
+			errors.New("unknown field"),
+		),
+	), positions)
+
+	rep.Sort()
+
+	return rep
+}
+
+func TestAddError(t *testing.T) {
+	rep := sampleReport()
+
+	require.Len(t, rep.Issues, 3)
+	require.Equal(t, "unknown field", rep.Issues[0].Message)
+	require.Equal(t, report.Issue{
+		File:     "clients/demo.yaml",
+		Line:     1,
+		Column:   1,
+		Pointer:  "/clients/c1/client_name",
+		Rule:     report.RuleValidation,
+		Severity: report.SeverityError,
+		Message:  "clients.c1.client_name in body is required",
+	}, rep.Issues[1])
+	require.Equal(t, "clients/demo.yaml:3:1", rep.Issues[2].Location())
+	require.Equal(t, 3, rep.Count(report.SeverityError))
+	require.Equal(t, 0, rep.Count(report.SeverityWarning))
+}
+
+func TestWrite(t *testing.T) {
+	t.Run("text", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		require.NoError(t, sampleReport().Write(&buf, report.FormatText, "cac"))
+		require.Equal(t, `error: unknown field (validation)
+clients/demo.yaml:1:1: error: clients.c1.client_name in body is required (validation)
+clients/demo.yaml:3:1: error: invalid value (validation)
+3 errors, 0 warnings
+`, buf.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		require.NoError(t, (&report.Report{}).Write(&buf, report.FormatJSON, "cac"))
+		require.JSONEq(t, `{"issues": [], "errors": 0, "warnings": 0}`, buf.String())
+	})
+
+	t.Run("junit", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		require.NoError(t, sampleReport().Write(&buf, report.FormatJUnit, "cac"))
+		require.Contains(t, buf.String(), `<testsuite name="cac" tests="3" failures="3">`)
+		require.Contains(t, buf.String(), `classname="clients/demo.yaml:3:1" file="clients/demo.yaml" line="3"`)
+	})
+
+	t.Run("junit without issues", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		require.NoError(t, (&report.Report{}).Write(&buf, report.FormatJUnit, "cac"))
+		require.Contains(t, buf.String(), `<testsuite name="cac" tests="1" failures="0">`)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := report.FormatFromString("xml")
+		require.ErrorIs(t, err, report.ErrUnknownFormat)
+	})
+}
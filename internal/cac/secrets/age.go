@@ -0,0 +1,274 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/pkg/errors"
+)
+
+// AgeKeyFileEnv is the environment variable with the identity file used when no identities are configured
+const AgeKeyFileEnv = "CAC_AGE_KEY_FILE"
+
+type AgeConfiguration struct {
+	// Recipients are public keys values are encrypted for, i.e. age1...
+	Recipients []string `json:"recipients"`
+	// Identities are files with private keys used to decrypt values, generated with age-keygen
+	Identities []string `json:"identities"`
+}
+
+var (
+	ErrNoRecipients = errors.New("no age recipients configured in secrets.age.recipients")
+	ErrNoIdentities = errors.New("no age identities configured in secrets.age.identities or " + AgeKeyFileEnv)
+
+	encryptedRegexp = regexp.MustCompile(`ENC\[age,([A-Za-z0-9+/=]+)\]`)
+)
+
+// IsEncrypted returns true if the value is encrypted with Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, "ENC[age,") && strings.HasSuffix(value, "]")
+}
+
+// Encrypt encrypts the value for configured recipients, the result has the form ENC[age,<base64>]
+func (c *AgeConfiguration) Encrypt(value string) (string, error) {
+	if c == nil {
+		return "", ErrNoRecipients
+	}
+
+	return encrypt(value, c.Recipients)
+}
+
+func encrypt(value string, recipients []string) (string, error) {
+	var (
+		parsed []age.Recipient
+		buf    bytes.Buffer
+		w      io.WriteCloser
+		err    error
+	)
+
+	if len(recipients) == 0 {
+		return "", ErrNoRecipients
+	}
+
+	if parsed, err = age.ParseRecipients(strings.NewReader(strings.Join(recipients, "\n"))); err != nil {
+		return "", errors.Wrap(err, "failed to parse age recipients")
+	}
+
+	if w, err = age.Encrypt(&buf, parsed...); err != nil {
+		return "", errors.Wrap(err, "failed to encrypt value")
+	}
+
+	if _, err = io.WriteString(w, value); err != nil {
+		return "", errors.Wrap(err, "failed to encrypt value")
+	}
+
+	if err = w.Close(); err != nil {
+		return "", errors.Wrap(err, "failed to encrypt value")
+	}
+
+	return "ENC[age," + base64.StdEncoding.EncodeToString(buf.Bytes()) + "]", nil
+}
+
+// Decrypt decrypts the value encrypted with Encrypt using configured identities
+func (c *AgeConfiguration) Decrypt(value string) (string, error) {
+	var (
+		identities []age.Identity
+		err        error
+	)
+
+	if identities, err = c.readIdentities(); err != nil {
+		return "", err
+	}
+
+	return decrypt(value, identities)
+}
+
+func decrypt(value string, identities []age.Identity) (string, error) {
+	var (
+		match = encryptedRegexp.FindStringSubmatch(value)
+		bts   []byte
+		r     io.Reader
+		err   error
+	)
+
+	if match == nil {
+		return "", errors.New("value is not encrypted")
+	}
+
+	if bts, err = base64.StdEncoding.DecodeString(match[1]); err != nil {
+		return "", errors.Wrap(err, "failed to decode encrypted value")
+	}
+
+	if r, err = age.Decrypt(bytes.NewReader(bts), identities...); err != nil {
+		return "", errors.Wrap(err, "failed to decrypt value")
+	}
+
+	if bts, err = io.ReadAll(r); err != nil {
+		return "", errors.Wrap(err, "failed to decrypt value")
+	}
+
+	return string(bts), nil
+}
+
+// readIdentities reads identities on demand, so keys are required only when encrypted values are read
+// a nil configuration falls back to the identity file from the environment
+func (c *AgeConfiguration) readIdentities() ([]age.Identity, error) {
+	var (
+		files      []string
+		identities []age.Identity
+	)
+
+	if c != nil {
+		files = c.Identities
+	}
+
+	if len(files) == 0 {
+		if file := os.Getenv(AgeKeyFileEnv); file != "" {
+			files = []string{file}
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, ErrNoIdentities
+	}
+
+	for _, file := range files {
+		var (
+			f   *os.File
+			ids []age.Identity
+			err error
+		)
+
+		if f, err = os.Open(file); err != nil {
+			return nil, errors.Wrap(err, "failed to open age identity file")
+		}
+
+		ids, err = age.ParseIdentities(f)
+		f.Close()
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse age identity file %s", file)
+		}
+
+		identities = append(identities, ids...)
+	}
+
+	return identities, nil
+}
+
+// DecryptValues decrypts encrypted strings nested in the value in place
+func (c *AgeConfiguration) DecryptValues(value any) error {
+	var identities []age.Identity
+
+	return walkStrings(value, func(s string) (string, error) {
+		var err error
+
+		if !IsEncrypted(s) {
+			return s, nil
+		}
+
+		if identities == nil {
+			if identities, err = c.readIdentities(); err != nil {
+				return "", err
+			}
+		}
+
+		return decrypt(s, identities)
+	})
+}
+
+func walkStrings(value any, fn func(string) (string, error)) error {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, it := range v {
+			if s, ok := it.(string); ok {
+				var err error
+
+				if v[k], err = fn(s); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if err := walkStrings(it, fn); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for i, it := range v {
+			if s, ok := it.(string); ok {
+				var err error
+
+				if v[i], err = fn(s); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if err := walkStrings(it, fn); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// DecryptText decrypts all encrypted values found in the text, i.e. a configuration file
+func (c *AgeConfiguration) DecryptText(bts []byte) ([]byte, error) {
+	return c.replaceText(bts, func(identities []age.Identity, value string) (string, error) {
+		return decrypt(value, identities)
+	})
+}
+
+// ReencryptText encrypts all encrypted values found in the text for currently configured recipients
+// templates and comments of the text are kept intact
+func (c *AgeConfiguration) ReencryptText(bts []byte) ([]byte, error) {
+	return c.replaceText(bts, func(identities []age.Identity, value string) (string, error) {
+		plain, err := decrypt(value, identities)
+
+		if err != nil {
+			return "", err
+		}
+
+		return c.Encrypt(plain)
+	})
+}
+
+func (c *AgeConfiguration) replaceText(bts []byte, fn func([]age.Identity, string) (string, error)) ([]byte, error) {
+	var (
+		identities []age.Identity
+		err        error
+	)
+
+	if !encryptedRegexp.Match(bts) {
+		return bts, nil
+	}
+
+	if identities, err = c.readIdentities(); err != nil {
+		return nil, err
+	}
+
+	out := encryptedRegexp.ReplaceAllFunc(bts, func(match []byte) []byte {
+		if err != nil {
+			return match
+		}
+
+		var s string
+
+		if s, err = fn(identities, string(match)); err != nil {
+			return match
+		}
+
+		return []byte(s)
+	})
+
+	return out, err
+}
@@ -0,0 +1,101 @@
+package secrets_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/cloudentity/cac/internal/cac/secrets"
+	"github.com/cloudentity/cac/internal/cac/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func generateIdentity(t *testing.T, dir string, name string) (string, string) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	file := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(file, []byte(identity.String()+"\n"), 0600))
+
+	return file, identity.Recipient().String()
+}
+
+func TestAge(t *testing.T) {
+	var (
+		dir                  = t.TempDir()
+		oldKey, oldRecipient = generateIdentity(t, dir, "old.txt")
+		newKey, newRecipient = generateIdentity(t, dir, "new.txt")
+		ageConfig            = &secrets.AgeConfiguration{Recipients: []string{oldRecipient}, Identities: []string{oldKey}}
+	)
+
+	encrypted, err := ageConfig.Encrypt("s3cr3t")
+	require.NoError(t, err)
+	require.True(t, secrets.IsEncrypted(encrypted))
+
+	decrypted, err := ageConfig.Decrypt(encrypted)
+	require.NoError(t, err)
+	require.Equal(t, "s3cr3t", decrypted)
+
+	t.Run("read encrypted values from storage", func(t *testing.T) {
+		data := filepath.Join(dir, "data")
+		path := filepath.Join(data, "workspaces", "demo", "clients", "app.yaml")
+
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("id: c1\nclient_name: app\nclient_secret: "+encrypted+"\nrotated_secrets:\n  - "+encrypted+"\n"), 0600))
+
+		read, err := storage.InitServerStorage(&storage.Configuration{DirPath: data, Age: ageConfig}).Read(context.Background(), api.WithWorkspace("demo"))
+		require.NoError(t, err)
+
+		client := read["clients"].(map[string]any)["c1"].(map[string]any)
+		require.Equal(t, "s3cr3t", client["client_secret"])
+		require.Equal(t, []any{"s3cr3t"}, client["rotated_secrets"])
+
+		read, err = storage.InitServerStorage(&storage.Configuration{DirPath: data, Age: ageConfig}).Read(context.Background(), api.WithWorkspace("demo"), api.WithEncrypted(true))
+		require.NoError(t, err)
+		require.Equal(t, encrypted, read["clients"].(map[string]any)["c1"].(map[string]any)["client_secret"])
+	})
+
+	t.Run("rotate key", func(t *testing.T) {
+		text := []byte("# comment\nclient_secret: " + encrypted + "\ndescription: {{ .Values.description }}\n")
+
+		// the old key decrypts values until rotation completes
+		rotating := &secrets.AgeConfiguration{Recipients: []string{newRecipient}, Identities: []string{oldKey, newKey}}
+
+		rotated, err := rotating.ReencryptText(text)
+		require.NoError(t, err)
+		require.NotEqual(t, string(text), string(rotated))
+		require.True(t, strings.HasPrefix(string(rotated), "# comment\nclient_secret: ENC[age,"))
+		require.True(t, strings.HasSuffix(string(rotated), "]\ndescription: {{ .Values.description }}\n"))
+
+		newOnly := &secrets.AgeConfiguration{Identities: []string{newKey}}
+
+		plain, err := newOnly.DecryptText(rotated)
+		require.NoError(t, err)
+		require.Equal(t, "# comment\nclient_secret: s3cr3t\ndescription: {{ .Values.description }}\n", string(plain))
+
+		_, err = newOnly.DecryptText(text)
+		require.ErrorContains(t, err, "failed to decrypt value")
+	})
+
+	t.Run("missing keys", func(t *testing.T) {
+		var empty *secrets.AgeConfiguration
+
+		t.Setenv(secrets.AgeKeyFileEnv, "")
+
+		_, err := empty.Encrypt("s3cr3t")
+		require.ErrorIs(t, err, secrets.ErrNoRecipients)
+
+		_, err = empty.Decrypt(encrypted)
+		require.ErrorIs(t, err, secrets.ErrNoIdentities)
+
+		t.Setenv(secrets.AgeKeyFileEnv, oldKey)
+
+		decrypted, err := empty.Decrypt(encrypted)
+		require.NoError(t, err)
+		require.Equal(t, "s3cr3t", decrypted)
+	})
+}
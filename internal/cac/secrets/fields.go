@@ -0,0 +1,81 @@
+package secrets
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/provenance"
+)
+
+// Replacer returns the value replacing the secret found under the pointer
+type Replacer func(pointer string, value string) (any, error)
+
+// Matcher returns true if the value under the JSON pointer is a secret field
+type Matcher func(pointer string) bool
+
+// Replace replaces values of secret fields in the configuration, empty values are left intact
+// all string values nested in a matched object are secret
+func Replace(data models.Rfc7396PatchOperation, secret Matcher, replacer Replacer) error {
+	return replace(data, "", false, secret, replacer)
+}
+
+func replace(node any, pointer string, inSecret bool, secret Matcher, replacer Replacer) error {
+	var set func(key string, value any)
+
+	switch n := node.(type) {
+	case models.Rfc7396PatchOperation:
+		return replace(map[string]any(n), pointer, inSecret, secret, replacer)
+	case map[string]any:
+		set = func(key string, value any) { n[key] = value }
+
+		for k, v := range n {
+			if err := replaceValue(k, v, pointer, inSecret, secret, replacer, set); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for i, v := range n {
+			set = func(_ string, value any) { n[i] = value }
+
+			if err := replaceValue(strconv.Itoa(i), v, pointer, inSecret, secret, replacer, set); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func replaceValue(key string, value any, pointer string, inSecret bool, secret Matcher, replacer Replacer, set func(string, any)) error {
+	var (
+		p       = provenance.Join(pointer, key)
+		matched = inSecret || secret(p)
+	)
+
+	s, ok := value.(string)
+
+	if !ok {
+		return replace(value, p, matched, secret, replacer)
+	}
+
+	if !matched || s == "" {
+		return nil
+	}
+
+	replaced, err := replacer(p, s)
+
+	if err != nil {
+		return err
+	}
+
+	set(key, replaced)
+
+	return nil
+}
+
+// Expression returns the template expression resolving the reference
+// the value is rendered as a JSON string, so it is a valid YAML scalar whatever characters it contains
+func Expression(ref string) string {
+	return fmt.Sprintf("secret %q | toJson", ref)
+}
@@ -0,0 +1,119 @@
+package secrets
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var ErrSecretNotFound = errors.New("secret not found")
+
+// FileProvider reads secrets from files, i.e. mounted with docker or kubernetes secrets
+// relative paths are resolved against the execution directory
+type FileProvider struct {
+	// DirPath is the directory where written secrets are stored
+	DirPath string
+}
+
+var _ Writer = &FileProvider{}
+
+func (f *FileProvider) Read(key string) (string, error) {
+	bts, err := os.ReadFile(filepath.FromSlash(key))
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", errors.Wrapf(ErrSecretNotFound, "file %s", key)
+		}
+
+		return "", err
+	}
+
+	// editors and secret mounts often end files with a newline which is not a part of the secret
+	return strings.TrimSuffix(string(bts), "\n"), nil
+}
+
+func (f *FileProvider) Write(key string, value string) (string, error) {
+	var path = filepath.Join(f.DirPath, filepath.FromSlash(key))
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", errors.Wrap(err, "failed to create secrets directory")
+	}
+
+	if err := os.WriteFile(path, []byte(value), 0600); err != nil {
+		return "", errors.Wrap(err, "failed to write secret")
+	}
+
+	return "file:" + filepath.ToSlash(path), nil
+}
+
+// EnvProvider reads secrets from environment variables
+type EnvProvider struct{}
+
+func (e *EnvProvider) Read(key string) (string, error) {
+	if value, ok := os.LookupEnv(key); ok {
+		return value, nil
+	}
+
+	return "", errors.Wrapf(ErrSecretNotFound, "environment variable %s", key)
+}
+
+type CommandConfiguration struct {
+	// Read is the command printing the secret, the key is appended as the last argument
+	Read []string `json:"read"`
+	// Write is the optional command storing the secret passed on stdin, the key is appended as the last argument
+	Write []string `json:"write"`
+}
+
+// CommandProvider reads and writes secrets with external commands
+type CommandProvider struct {
+	Name   string
+	Config CommandConfiguration
+}
+
+var _ Writer = &CommandProvider{}
+
+func (c *CommandProvider) Read(key string) (string, error) {
+	out, err := run(c.Config.Read, key, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+func (c *CommandProvider) Write(key string, value string) (string, error) {
+	if len(c.Config.Write) == 0 {
+		return "", errors.Wrapf(ErrReadOnlyProvider, "%s has no write command", c.Name)
+	}
+
+	if _, err := run(c.Config.Write, key, strings.NewReader(value)); err != nil {
+		return "", err
+	}
+
+	return c.Name + ":" + key, nil
+}
+
+func run(command []string, key string, stdin *strings.Reader) (string, error) {
+	var (
+		stdout bytes.Buffer
+		stderr bytes.Buffer
+		cmd    = exec.Command(command[0], append(command[1:], key)...)
+	)
+
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrapf(err, "%s failed: %s", command[0], strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
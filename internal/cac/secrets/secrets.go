@@ -0,0 +1,170 @@
+package secrets
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slog"
+)
+
+var DefaultConfig = func() *Configuration {
+	return &Configuration{
+		DirPath: ".secrets",
+	}
+}
+
+type Configuration struct {
+	// DirPath is the directory where the file provider stores secrets pulled with --secrets-to file
+	DirPath string `json:"dir_path"`
+	// Commands are providers reading and writing secrets with external commands, i.e. a vault cli
+	// provider names are used as reference schemes, i.e. vault:path/to/secret
+	Commands map[string]CommandConfiguration `json:"commands"`
+	// Age configures encryption of secret values stored in configuration files
+	Age *AgeConfiguration `json:"age"`
+}
+
+// Provider reads secrets by references
+// a reference has the form <scheme>:<key>, where the scheme is the name of a registered provider
+type Provider interface {
+	// Read returns the secret value stored under the key
+	Read(key string) (string, error)
+}
+
+// Writer is implemented by providers able to store secrets
+type Writer interface {
+	// Write stores the secret value under the key and returns the reference of the stored secret
+	Write(key string, value string) (string, error)
+}
+
+var (
+	ErrUnknownProvider  = errors.New("unknown secret provider")
+	ErrReadOnlyProvider = errors.New("secret provider does not support writing secrets")
+	ErrInvalidReference = errors.New("invalid secret reference")
+
+	mu        sync.RWMutex
+	providers = map[string]Provider{}
+)
+
+func init() {
+	Register("file", &FileProvider{DirPath: DefaultConfig().DirPath})
+	Register("env", &EnvProvider{})
+}
+
+// Register makes the provider available under the scheme, replacing a provider already registered under it
+func Register(scheme string, provider Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	providers[scheme] = provider
+}
+
+// Lookup returns the provider registered under the scheme
+func Lookup(scheme string) (Provider, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if p, ok := providers[scheme]; ok {
+		return p, nil
+	}
+
+	return nil, errors.Wrapf(ErrUnknownProvider, "%s, available: %s", scheme, strings.Join(schemes(), ", "))
+}
+
+func schemes() []string {
+	var out = make([]string, 0, len(providers))
+
+	for s := range providers {
+		out = append(out, s)
+	}
+
+	sort.Strings(out)
+
+	return out
+}
+
+// InitSecrets registers providers configured by the configuration
+func InitSecrets(config *Configuration) error {
+	if config == nil {
+		return nil
+	}
+
+	Register("file", &FileProvider{DirPath: config.DirPath})
+
+	for name, c := range config.Commands {
+		if name == "file" || name == "env" {
+			return errors.Errorf("command provider %s overrides a built-in provider", name)
+		}
+
+		if len(c.Read) == 0 {
+			return errors.Errorf("command provider %s requires a read command", name)
+		}
+
+		Register(name, &CommandProvider{Name: name, Config: c})
+	}
+
+	slog.Debug("initiated secret providers", "providers", schemes())
+
+	return nil
+}
+
+// ParseReference splits the reference into the provider scheme and the key
+// file references accept also file URLs, i.e. file:///run/secrets/x
+func ParseReference(ref string) (string, string, error) {
+	scheme, key, ok := strings.Cut(ref, ":")
+
+	if !ok || scheme == "" || key == "" {
+		return "", "", errors.Wrapf(ErrInvalidReference, "%q, expected <provider>:<key>", ref)
+	}
+
+	if scheme == "file" && strings.HasPrefix(key, "//") {
+		key = strings.TrimPrefix(key, "//")
+	}
+
+	return scheme, key, nil
+}
+
+// Resolve returns the secret value of the reference
+func Resolve(ref string) (string, error) {
+	var (
+		scheme, key string
+		provider    Provider
+		value       string
+		err         error
+	)
+
+	if scheme, key, err = ParseReference(ref); err != nil {
+		return "", err
+	}
+
+	if provider, err = Lookup(scheme); err != nil {
+		return "", err
+	}
+
+	if value, err = provider.Read(key); err != nil {
+		return "", errors.Wrapf(err, "failed to read secret %s", ref)
+	}
+
+	return value, nil
+}
+
+// Store writes the secret value with the provider registered under the scheme and returns its reference
+func Store(scheme string, key string, value string) (string, error) {
+	var (
+		provider Provider
+		writer   Writer
+		ok       bool
+		err      error
+	)
+
+	if provider, err = Lookup(scheme); err != nil {
+		return "", err
+	}
+
+	if writer, ok = provider.(Writer); !ok {
+		return "", errors.Wrapf(ErrReadOnlyProvider, "%s", scheme)
+	}
+
+	return writer.Write(key, value)
+}
@@ -0,0 +1,171 @@
+package secrets_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/cloudentity/cac/internal/cac/diff"
+	"github.com/cloudentity/cac/internal/cac/secrets"
+	"github.com/cloudentity/cac/internal/cac/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		file = filepath.Join(dir, "secret")
+	)
+
+	require.NoError(t, os.WriteFile(file, []byte("from file\n"), 0600))
+	t.Setenv("CAC_TEST_SECRET", "from env")
+
+	tcs := []struct {
+		ref      string
+		expected string
+		err      error
+	}{
+		{ref: "file://" + filepath.ToSlash(file), expected: "from file"},
+		{ref: "file:" + filepath.ToSlash(file), expected: "from file"},
+		{ref: "env:CAC_TEST_SECRET", expected: "from env"},
+		{ref: "env:CAC_TEST_MISSING", err: secrets.ErrSecretNotFound},
+		{ref: "file:" + filepath.Join(dir, "missing"), err: secrets.ErrSecretNotFound},
+		{ref: "vault:secret", err: secrets.ErrUnknownProvider},
+		{ref: "secret", err: secrets.ErrInvalidReference},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.ref, func(t *testing.T) {
+			value, err := secrets.Resolve(tc.ref)
+
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, value)
+		})
+	}
+}
+
+func TestCommandProvider(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake command requires a shell")
+	}
+
+	var (
+		dir  = t.TempDir()
+		tool = filepath.Join(dir, "tool")
+	)
+
+	// fake tool stores secrets as files named by keys
+	require.NoError(t, os.WriteFile(tool, []byte(`#!/bin/sh
+case "$1" in
+  get) cat "$(dirname "$0")/$2" ;;
+  put) cat > "$(dirname "$0")/$2" ;;
+esac
+`), 0700))
+
+	require.NoError(t, secrets.InitSecrets(&secrets.Configuration{
+		DirPath: dir,
+		Commands: map[string]secrets.CommandConfiguration{
+			"tool":     {Read: []string{tool, "get"}, Write: []string{tool, "put"}},
+			"readonly": {Read: []string{tool, "get"}},
+		},
+	}))
+
+	ref, err := secrets.Store("tool", "key", "value")
+	require.NoError(t, err)
+	require.Equal(t, "tool:key", ref)
+
+	value, err := secrets.Resolve(ref)
+	require.NoError(t, err)
+	require.Equal(t, "value", value)
+
+	_, err = secrets.Store("readonly", "key", "value")
+	require.ErrorIs(t, err, secrets.ErrReadOnlyProvider)
+
+	_, err = secrets.Store("env", "key", "value")
+	require.ErrorIs(t, err, secrets.ErrReadOnlyProvider)
+
+	require.Error(t, secrets.InitSecrets(&secrets.Configuration{
+		Commands: map[string]secrets.CommandConfiguration{"env": {Read: []string{tool}}},
+	}))
+}
+
+func TestReplace(t *testing.T) {
+	data := models.Rfc7396PatchOperation{
+		"mfa_methods": map[string]any{
+			"sms": map[string]any{
+				"mechanism": "sms",
+				"auth": map[string]any{
+					"sms": map[string]any{"sid": "sid", "token": "token", "source": ""},
+				},
+			},
+		},
+		"servers": map[string]any{
+			"demo": map[string]any{
+				"secret": "server secret",
+				"clients": map[string]any{
+					"c1": map[string]any{"client_name": "app", "client_secret": "client secret"},
+				},
+				"webhooks": map[string]any{
+					"w1": map[string]any{"url": "https://example.com", "api_key": "api key"},
+				},
+			},
+		},
+	}
+
+	replaced := map[string]string{}
+
+	require.NoError(t, secrets.Replace(data, diff.IsSecret, func(pointer string, value string) (any, error) {
+		replaced[pointer] = value
+		return "ref", nil
+	}))
+
+	require.Equal(t, map[string]string{
+		"/mfa_methods/sms/auth/sms/sid":          "sid",
+		"/mfa_methods/sms/auth/sms/token":        "token",
+		"/servers/demo/secret":                   "server secret",
+		"/servers/demo/clients/c1/client_secret": "client secret",
+		"/servers/demo/webhooks/w1/api_key":      "api key",
+	}, replaced)
+	require.Equal(t, "app", data["servers"].(map[string]any)["demo"].(map[string]any)["clients"].(map[string]any)["c1"].(map[string]any)["client_name"])
+}
+
+func TestPullSecretsToFile(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		data = models.Rfc7396PatchOperation{
+			"name": "demo",
+			"clients": map[string]any{
+				"c1": map[string]any{"client_name": "app", "client_secret": `s3cr3t: with "quotes"`},
+			},
+		}
+	)
+
+	require.NoError(t, secrets.InitSecrets(&secrets.Configuration{DirPath: filepath.Join(dir, ".secrets")}))
+
+	require.NoError(t, secrets.Replace(data, diff.IsSecret, func(pointer string, value string) (any, error) {
+		ref, err := secrets.Store("file", "demo"+pointer, value)
+		require.NoError(t, err)
+
+		return storage.InlineTemplate(secrets.Expression(ref)), nil
+	}))
+
+	st := storage.InitServerStorage(&storage.Configuration{DirPath: filepath.Join(dir, "data")})
+	require.NoError(t, st.Write(context.Background(), data, api.WithWorkspace("demo")))
+
+	bts, err := os.ReadFile(filepath.Join(dir, "data", "workspaces", "demo", "clients", "app.yaml"))
+	require.NoError(t, err)
+	require.Contains(t, string(bts), `client_secret: {{ secret "file:`+filepath.ToSlash(filepath.Join(dir, ".secrets", "demo", "clients", "c1", "client_secret"))+`" | toJson }}`)
+
+	read, err := st.Read(context.Background(), api.WithWorkspace("demo"))
+	require.NoError(t, err)
+	require.Equal(t, `s3cr3t: with "quotes"`, read["clients"].(map[string]any)["c1"].(map[string]any)["client_secret"])
+}
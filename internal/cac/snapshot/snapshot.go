@@ -0,0 +1,282 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slog"
+)
+
+type Configuration struct {
+	DirPath   string `json:"dir_path"`
+	Retention int    `json:"retention"`
+}
+
+var DefaultConfig = func() *Configuration {
+	return &Configuration{
+		DirPath:   ".snapshots",
+		Retention: 10,
+	}
+}
+
+var (
+	ErrSnapshotNotFound  = errors.New("snapshot not found")
+	ErrInvalidSnapshotID = errors.New("invalid snapshot id")
+)
+
+const timeFormat = "20060102T150405.000Z"
+
+// idRegexp matches ids generated by snapshotID, so an id cannot point outside of the snapshots directory
+var idRegexp = regexp.MustCompile(`^\d{8}T\d{6}\.\d{3}Z-[\w-]+$`)
+
+// Snapshot is a copy of remote configuration including secrets
+type Snapshot struct {
+	ID        string                       `json:"id"`
+	Workspace string                       `json:"workspace,omitempty"`
+	Tenant    bool                         `json:"tenant,omitempty"`
+	CreatedAt time.Time                    `json:"created_at"`
+	Data      models.Rfc7396PatchOperation `json:"data,omitempty"`
+}
+
+func InitStore(config *Configuration) *Store {
+	return &Store{
+		Config: config,
+	}
+}
+
+type Store struct {
+	Config *Configuration
+}
+
+// Create exports remote configuration with secrets and stores it as a new snapshot
+func (s *Store) Create(ctx context.Context, source api.Source, workspace string, tenant bool) (*Snapshot, error) {
+	var (
+		now  = time.Now().UTC()
+		snap = &Snapshot{
+			ID:        snapshotID(now, workspace, tenant),
+			Workspace: workspace,
+			Tenant:    tenant,
+			CreatedAt: now,
+		}
+		bts []byte
+		err error
+	)
+
+	if !idRegexp.MatchString(snap.ID) {
+		return nil, errors.Wrapf(ErrInvalidSnapshotID, "workspace %s", workspace)
+	}
+
+	if snap.Data, err = source.Read(ctx, api.WithWorkspace(workspace), api.WithSecrets(true)); err != nil {
+		return nil, errors.Wrap(err, "failed to read configuration")
+	}
+
+	if err = os.MkdirAll(s.Config.DirPath, 0700); err != nil {
+		return nil, errors.Wrap(err, "failed to create snapshots directory")
+	}
+
+	if bts, err = json.Marshal(snap, json.Deterministic(true)); err != nil {
+		return nil, errors.Wrap(err, "failed to marshal snapshot")
+	}
+
+	// snapshots contain secrets
+	if err = os.WriteFile(s.path(snap.ID), bts, 0600); err != nil {
+		return nil, errors.Wrap(err, "failed to write snapshot")
+	}
+
+	slog.Info("Created snapshot", "id", snap.ID, "path", s.path(snap.ID))
+
+	return snap, nil
+}
+
+// List returns snapshots of the workspace or tenant without data, sorted from the newest
+// only the metadata preceding the data is decoded
+func (s *Store) List(workspace string, tenant bool) ([]*Snapshot, error) {
+	var (
+		entries []os.DirEntry
+		out     []*Snapshot
+		err     error
+	)
+
+	if entries, err = os.ReadDir(s.Config.DirPath); err != nil {
+		if os.IsNotExist(err) {
+			return out, nil
+		}
+
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		var (
+			snap *Snapshot
+			id   = strings.TrimSuffix(entry.Name(), ".json")
+		)
+
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" || !idRegexp.MatchString(id) {
+			continue
+		}
+
+		if snap, err = s.header(id); err != nil {
+			return nil, err
+		}
+
+		if snap.Workspace != workspace || snap.Tenant != tenant {
+			continue
+		}
+
+		out = append(out, snap)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].CreatedAt.After(out[j].CreatedAt)
+	})
+
+	return out, nil
+}
+
+func (s *Store) Get(id string) (*Snapshot, error) {
+	var (
+		snap = &Snapshot{}
+		bts  []byte
+		err  error
+	)
+
+	if !idRegexp.MatchString(id) {
+		return nil, errors.Wrapf(ErrInvalidSnapshotID, "snapshot %s", id)
+	}
+
+	if bts, err = os.ReadFile(s.path(id)); err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Wrapf(ErrSnapshotNotFound, "snapshot %s", id)
+		}
+
+		return nil, err
+	}
+
+	if err = json.Unmarshal(bts, snap); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal snapshot %s", id)
+	}
+
+	return snap, nil
+}
+
+// header decodes the snapshot without its data, which is written last, so listing does not read whole snapshots
+func (s *Store) header(id string) (*Snapshot, error) {
+	var (
+		snap = &Snapshot{}
+		file *os.File
+		tok  jsontext.Token
+		err  error
+	)
+
+	if file, err = os.Open(s.path(id)); err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	dec := jsontext.NewDecoder(file)
+
+	if tok, err = dec.ReadToken(); err != nil || tok.Kind() != '{' {
+		return nil, errors.Errorf("snapshot %s is not a json object", id)
+	}
+
+	for dec.PeekKind() == '"' {
+		if tok, err = dec.ReadToken(); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode snapshot %s", id)
+		}
+
+		switch tok.String() {
+		case "data":
+			return snap, nil
+		case "id":
+			err = json.UnmarshalDecode(dec, &snap.ID)
+		case "workspace":
+			err = json.UnmarshalDecode(dec, &snap.Workspace)
+		case "tenant":
+			err = json.UnmarshalDecode(dec, &snap.Tenant)
+		case "created_at":
+			err = json.UnmarshalDecode(dec, &snap.CreatedAt)
+		default:
+			err = dec.SkipValue()
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode snapshot %s", id)
+		}
+	}
+
+	return snap, nil
+}
+
+// Prune removes the oldest snapshots of the workspace or tenant keeping at most retention snapshots
+func (s *Store) Prune(workspace string, tenant bool, retention int) ([]string, error) {
+	var (
+		snapshots []*Snapshot
+		removed   []string
+		err       error
+	)
+
+	if retention <= 0 {
+		return removed, nil
+	}
+
+	if snapshots, err = s.List(workspace, tenant); err != nil {
+		return nil, err
+	}
+
+	for i := retention; i < len(snapshots); i++ {
+		if err = os.Remove(s.path(snapshots[i].ID)); err != nil {
+			return removed, errors.Wrapf(err, "failed to remove snapshot %s", snapshots[i].ID)
+		}
+
+		slog.Debug("Removed snapshot", "id", snapshots[i].ID)
+		removed = append(removed, snapshots[i].ID)
+	}
+
+	return removed, nil
+}
+
+// Restore imports snapshot data replacing the remote configuration
+func (s *Store) Restore(ctx context.Context, target api.Source, id string, mode string) (*Snapshot, error) {
+	var (
+		snap *Snapshot
+		err  error
+	)
+
+	if snap, err = s.Get(id); err != nil {
+		return nil, err
+	}
+
+	if err = target.Write(ctx, snap.Data,
+		api.WithWorkspace(snap.Workspace),
+		api.WithMode(mode),
+		api.WithMethod("import"),
+	); err != nil {
+		return nil, errors.Wrapf(err, "failed to restore snapshot %s", id)
+	}
+
+	return snap, nil
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.Config.DirPath, id+".json")
+}
+
+func snapshotID(t time.Time, workspace string, tenant bool) string {
+	if tenant {
+		return fmt.Sprintf("%s-tenant", t.Format(timeFormat))
+	}
+
+	return fmt.Sprintf("%s-%s", t.Format(timeFormat), workspace)
+}
@@ -0,0 +1,113 @@
+package snapshot_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/cloudentity/cac/internal/cac/snapshot"
+	"github.com/stretchr/testify/require"
+)
+
+type memorySource struct {
+	data    models.Rfc7396PatchOperation
+	written []models.Rfc7396PatchOperation
+	methods []string
+}
+
+func (m *memorySource) Read(ctx context.Context, opts ...api.SourceOpt) (models.Rfc7396PatchOperation, error) {
+	var out = models.Rfc7396PatchOperation{}
+
+	for k, v := range m.data {
+		out[k] = v
+	}
+
+	return out, nil
+}
+
+func (m *memorySource) Write(ctx context.Context, data models.Rfc7396PatchOperation, opts ...api.SourceOpt) error {
+	var options = &api.Options{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	m.written = append(m.written, data)
+	m.methods = append(m.methods, options.Method)
+
+	return nil
+}
+
+func (m *memorySource) String() string {
+	return "memory"
+}
+
+func TestSnapshots(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		remote = &memorySource{data: models.Rfc7396PatchOperation{"name": "demo"}}
+		store  = snapshot.InitStore(&snapshot.Configuration{DirPath: t.TempDir()})
+		ids    []string
+	)
+
+	for i := 0; i < 3; i++ {
+		snap, err := store.Create(ctx, remote, "demo", false)
+		require.NoError(t, err)
+		ids = append(ids, snap.ID)
+
+		// snapshot ids have millisecond precision
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	_, err := store.Create(ctx, remote, "other", false)
+	require.NoError(t, err)
+
+	t.Run("list returns snapshots of the workspace from the newest", func(t *testing.T) {
+		snapshots, err := store.List("demo", false)
+		require.NoError(t, err)
+		require.Len(t, snapshots, 3)
+		require.Equal(t, ids[2], snapshots[0].ID)
+		require.Equal(t, ids[0], snapshots[2].ID)
+		require.Nil(t, snapshots[0].Data)
+	})
+
+	t.Run("restore imports snapshot data", func(t *testing.T) {
+		snap, err := store.Restore(ctx, remote, ids[0], "update")
+		require.NoError(t, err)
+		require.Equal(t, "demo", snap.Workspace)
+		require.Equal(t, []string{"import"}, remote.methods)
+		require.Equal(t, "demo", remote.written[0]["name"])
+	})
+
+	t.Run("restore fails for unknown snapshot", func(t *testing.T) {
+		_, err := store.Restore(ctx, remote, "20240101T000000.000Z-demo", "update")
+		require.ErrorIs(t, err, snapshot.ErrSnapshotNotFound)
+	})
+
+	t.Run("ids outside of the snapshots directory are rejected", func(t *testing.T) {
+		for _, id := range []string{"../../x", "20240101T000000.000Z-demo/../../x", "missing", ""} {
+			_, err := store.Get(id)
+			require.ErrorIs(t, err, snapshot.ErrInvalidSnapshotID, id)
+		}
+
+		_, err := store.Create(ctx, remote, "../demo", false)
+		require.ErrorIs(t, err, snapshot.ErrInvalidSnapshotID)
+	})
+
+	t.Run("prune keeps the newest snapshots", func(t *testing.T) {
+		removed, err := store.Prune("demo", false, 1)
+		require.NoError(t, err)
+		require.ElementsMatch(t, ids[:2], removed)
+
+		snapshots, err := store.List("demo", false)
+		require.NoError(t, err)
+		require.Len(t, snapshots, 1)
+		require.Equal(t, ids[2], snapshots[0].ID)
+
+		snapshots, err = store.List("other", false)
+		require.NoError(t, err)
+		require.Len(t, snapshots, 1)
+	})
+}
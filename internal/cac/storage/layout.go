@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"path/filepath"
+
+	"github.com/cloudentity/cac/internal/cac/utils"
+)
+
+// single file collections stored under a different name than the patch key
+var collectionFiles = map[string]string{
+	"scopes_without_service":      "scopes",
+	"server_consent":              "consent",
+	"ciba_authentication_service": "ciba",
+}
+
+// WorkspaceDir returns the directory of the workspace relative to the storage root
+func WorkspaceDir(workspace string) string {
+	return filepath.Join("workspaces", workspace)
+}
+
+// CollectionFile returns the file, relative to the workspace directory, where a single file collection is stored
+func CollectionFile(key string) string {
+	if name, ok := collectionFiles[key]; ok {
+		key = name
+	}
+
+	return key + ".yaml"
+}
+
+// EntityFile returns the file, relative to the workspace directory, where an entity of the collection is stored
+// when several entities share a name, the storage adds a suffix which is not reflected here
+func EntityFile(collection string, id string, entity map[string]any) string {
+	var name = utils.EntityName(entity)
+
+	if name == "" || collection == "webhooks" {
+		name = id
+	}
+
+	return filepath.Join(collection, normalize(name)+".yaml")
+}
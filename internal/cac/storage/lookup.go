@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"maps"
+	"path/filepath"
+	"sort"
+
+	"github.com/cloudentity/cac/internal/cac/templates"
+	"github.com/cloudentity/cac/internal/cac/utils"
+	"github.com/pkg/errors"
+)
+
+// entityLookup resolves ids of workspace entities by names, entities are read from files of all layers on demand
+type entityLookup struct {
+	// paths are workspace paths of all layers in priority order
+	paths    []string
+	values   map[string]any
+	entities map[string]map[string]any
+	reading  map[string]bool
+}
+
+// lookup returns the lookup resolving names of entities in the workspace of all layers, i.e. policyID "MFA_User"
+func (s *ServerStorage) lookup(workspace string) templates.Lookup {
+	var (
+		layers = s.Config.Layers
+		l      = &entityLookup{
+			values:   s.Config.Values,
+			entities: map[string]map[string]any{},
+			reading:  map[string]bool{},
+		}
+	)
+
+	if len(layers) == 0 {
+		layers = []string{s.Config.DirPath}
+	}
+
+	for _, layer := range layers {
+		l.paths = append(l.paths, filepath.Join(layer, "workspaces", workspace))
+	}
+
+	return l.Lookup
+}
+
+func (l *entityLookup) Lookup(collection string, name string) (string, error) {
+	var err error
+
+	if _, ok := l.entities[collection]; !ok {
+		// entities are read only for their names and ids, so references between entities of the collection
+		// being read, i.e. a client referencing another client, are not resolved at this point
+		if l.reading[collection] {
+			return "", nil
+		}
+
+		l.reading[collection] = true
+		defer delete(l.reading, collection)
+
+		if l.entities[collection], err = l.read(collection); err != nil {
+			delete(l.entities, collection)
+			return "", errors.Wrapf(err, "failed to read %s to resolve %q", collection, name)
+		}
+	}
+
+	return findID(l.entities[collection], collection, name)
+}
+
+// read merges entities of the collection from all layers, entities of layers with higher priority override fields of lower ones
+func (l *entityLookup) read(collection string) (map[string]any, error) {
+	var out = map[string]any{}
+
+	for i := len(l.paths) - 1; i >= 0; i-- {
+		var (
+			entities map[string]any
+			err      error
+		)
+
+		if entities, err = readFiles(filepath.Join(l.paths[i], collection), WithValues(l.values), WithLookup(l.Lookup)); err != nil {
+			return nil, err
+		}
+
+		for id, it := range entities {
+			merged := maps.Clone(utils.AsMap(out[id]))
+			maps.Copy(merged, utils.AsMap(it))
+			out[id] = merged
+		}
+	}
+
+	return out, nil
+}
+
+// dataLookup resolves names of entities in the data, i.e. pulled configuration of a workspace
+func dataLookup(data map[string]any) templates.Lookup {
+	return func(collection string, name string) (string, error) {
+		return findID(utils.AsMap(data[collection]), collection, name)
+	}
+}
+
+func findID(entities map[string]any, collection string, name string) (string, error) {
+	var ids []string
+
+	for id, it := range entities {
+		if utils.EntityName(utils.AsMap(it)) == name {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+
+	switch len(ids) {
+	case 0:
+		return "", errors.Wrapf(templates.ErrEntityNotFound, "no %s named %q", collection, name)
+	case 1:
+		return ids[0], nil
+	default:
+		return "", errors.Errorf("%s name %q is ambiguous, it is used by: %v", collection, name, ids)
+	}
+}
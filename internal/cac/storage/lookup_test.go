@@ -0,0 +1,66 @@
+package storage_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/cloudentity/cac/internal/cac/storage"
+	"github.com/cloudentity/cac/internal/cac/templates"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageNameLookup(t *testing.T) {
+	var (
+		dir   = t.TempDir()
+		base  = filepath.Join(dir, "base")
+		local = filepath.Join(dir, "local")
+	)
+
+	writeFile(t, base, "workspaces/demo/server.yaml", "id: demo\nname: demo\n")
+	writeFile(t, base, "workspaces/demo/policies/MFA_User.yaml", "id: p1\npolicy_name: MFA_User\nlanguage: rego\n")
+	writeFile(t, base, "workspaces/demo/scripts/debug.yaml", "id: s1\nname: debug\n")
+	writeFile(t, base, "workspaces/demo/services/OAuth2.yaml", "id: svc1\nname: OAuth2\n")
+	writeFile(t, local, "workspaces/demo/clients/Financroo.yaml", `id: c1
+client_name: Financroo
+policy_id: {{ policyID "MFA_User" }}
+`)
+	writeFile(t, local, "workspaces/demo/clients/Debug.yaml", `id: c2
+client_name: {{ .Values.name }}
+description: {{ clientID "Financroo" }}
+`)
+	writeFile(t, local, "workspaces/demo/script_execution_points.yaml", `post_authn_ctx:
+  client:
+    {{ clientID "Financroo" }}:
+      script_id: {{ scriptID "debug" }}
+`)
+	writeFile(t, local, "workspaces/demo/services/OAuth2.yaml", "id: svc1\ndescription: {{ serviceID \"OAuth2\" }}\n")
+	writeFile(t, local, "values.yaml", "name: Debug\n")
+
+	st, err := storage.InitMultiStorage(&storage.MultiStorageConfiguration{
+		DirPath: []string{local, base},
+	}, storage.InitServerStorage)
+	require.NoError(t, err)
+
+	data, err := st.Read(context.Background(), api.WithWorkspace("demo"))
+	require.NoError(t, err)
+
+	clients := data["clients"].(map[string]any)
+	require.Equal(t, "p1", clients["c1"].(map[string]any)["policy_id"])
+	require.Equal(t, "c1", clients["c2"].(map[string]any)["description"])
+	require.Equal(t, "svc1", data["services"].(map[string]any)["svc1"].(map[string]any)["description"])
+	require.Equal(t, map[string]any{
+		"post_authn_ctx": map[string]any{
+			"client": map[string]any{"c1": map[string]any{"script_id": "s1"}},
+		},
+	}, data["script_execution_points"])
+
+	t.Run("unresolved name", func(t *testing.T) {
+		writeFile(t, local, "workspaces/demo/clients/Other.yaml", "id: c3\nclient_name: Other\npolicy_id: {{ policyID \"missing\" }}\n")
+
+		_, err = st.Read(context.Background(), api.WithWorkspace("demo"))
+		require.ErrorIs(t, err, templates.ErrEntityNotFound)
+		require.ErrorContains(t, err, `no policies named "missing"`)
+	})
+}
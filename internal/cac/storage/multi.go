@@ -3,15 +3,26 @@ package storage
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/cloudentity/acp-client-go/clients/hub/models"
 	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/cloudentity/cac/internal/cac/secrets"
+	"github.com/cloudentity/cac/internal/cac/templates"
+	"github.com/cloudentity/cac/internal/cac/utils"
 	"github.com/imdario/mergo"
 	"github.com/pkg/errors"
 )
 
 type MultiStorageConfiguration struct {
 	DirPath []string `json:"dir_path"`
+	// Overlays are directories with patches applied in order on top of the data merged from dir_path
+	Overlays []string `json:"overlays"`
+	// Values are files merged in order on top of values.yaml files found in dir_path, exposed to templates as .Values
+	Values []string `json:"values"`
+	// Age decrypts encrypted values of read files, it is set from the secrets configuration
+	Age *secrets.AgeConfiguration `json:"-"`
 }
 
 var DefaultMultiStorageConfig = func() *MultiStorageConfiguration {
@@ -23,27 +34,59 @@ var DefaultMultiStorageConfig = func() *MultiStorageConfiguration {
 type Constructor func(config *Configuration) Storage
 
 func InitMultiStorage(config *MultiStorageConfiguration, constr Constructor) (*MultiStorage, error) {
-	var storages []Storage
+	var (
+		storages []Storage
+		values   map[string]any
+		err      error
+	)
 
 	if len(config.DirPath) == 0 {
 		return nil, errors.New("at least one dir_path is required")
 	}
 
-	for _, config := range config.DirPath {
+	if values, err = readValues(config); err != nil {
+		return nil, err
+	}
+
+	for _, dir := range config.DirPath {
 		storages = append(storages, constr(&Configuration{
-			DirPath: config,
+			DirPath: dir,
+			Values:  values,
+			Layers:  config.DirPath,
+			Age:     config.Age,
 		}))
 	}
 
 	return &MultiStorage{
 		Storages: storages,
 		Config:   config,
+		Values:   values,
 	}, nil
 }
 
+// readValues merges values.yaml files found in dir_path, in the reverse order like data, with configured values files
+func readValues(config *MultiStorageConfiguration) (map[string]any, error) {
+	var files []string
+
+	for i := len(config.DirPath) - 1; i >= 0; i-- {
+		file := filepath.Join(config.DirPath[i], ValuesFile)
+
+		if _, err := os.Stat(file); err == nil {
+			files = append(files, file)
+		}
+	}
+
+	return templates.ReadValues(config.Age, append(files, config.Values...)...)
+}
+
+// ValuesFile is read from the root of each dir_path, so it can hold default values
+const ValuesFile = "values.yaml"
+
 type MultiStorage struct {
 	Storages []Storage
 	Config   *MultiStorageConfiguration
+	// Values are exposed to templates as .Values
+	Values map[string]any
 }
 
 var _ Storage = &MultiStorage{}
@@ -57,26 +100,53 @@ func (m *MultiStorage) Write(ctx context.Context, data models.Rfc7396PatchOperat
 // Read data from all storages and merge them
 func (m *MultiStorage) Read(ctx context.Context, opts ...api.SourceOpt) (models.Rfc7396PatchOperation, error) {
 	var (
-		data = models.Rfc7396PatchOperation{}
-		err  error
+		data     = models.Rfc7396PatchOperation{}
+		options  = &api.Options{}
+		readOpts = opts
+		overlays []Overlay
+		err      error
 	)
 
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	// overlays may patch entities which are filtered out, so filters are applied after overlays
+	if len(m.Config.Overlays) > 0 {
+		if overlays, err = readOverlays(m.Config.Overlays, options.Workspace, m.Values); err != nil {
+			return data, err
+		}
+
+		readOpts = append(readOpts[:len(readOpts):len(readOpts)], api.WithFilters(nil))
+	}
+
 	for i := len(m.Storages) - 1; i >= 0; i-- {
 		var data2 models.Rfc7396PatchOperation
 
-		if data2, err = m.Storages[i].Read(ctx, opts...); err != nil {
+		if data2, err = m.Storages[i].Read(ctx, readOpts...); err != nil {
 			return data, errors.Wrap(err, "failed to read data from storage")
 		}
 
 		if err = mergo.Merge(&data, data2, mergo.WithOverride); err != nil {
 			return data, errors.Wrap(err, "failed to merge data")
 		}
+	}
+
+	if len(overlays) == 0 {
+		return data, nil
+	}
 
+	if data, err = ApplyOverlays(data, overlays); err != nil {
+		return data, err
 	}
 
-	return data, nil
+	return utils.FilterPatch(data, options.Filters)
 }
 
 func (m *MultiStorage) String() string {
+	if len(m.Config.Overlays) > 0 {
+		return fmt.Sprintf("storage: %v, overlays: %v", m.Config.DirPath, m.Config.Overlays)
+	}
+
 	return fmt.Sprintf("storage: %v", m.Config.DirPath)
 }
@@ -0,0 +1,70 @@
+package storage_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/cloudentity/cac/internal/cac/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageValues(t *testing.T) {
+	var (
+		base    = t.TempDir()
+		local   = t.TempDir()
+		overlay = t.TempDir()
+		prod    = filepath.Join(t.TempDir(), "prod.yaml")
+	)
+
+	writeFile(t, base, "values.yaml", "name: base\nclient:\n  description: base\n  redirect_uris: [http://localhost/callback]\n")
+	writeFile(t, local, "values.yaml", "name: local\n")
+	writeFile(t, filepath.Dir(prod), "prod.yaml", "client:\n  redirect_uris: [https://app.example.com/callback]\n")
+
+	writeFile(t, base, "workspaces/demo/server.yaml", "id: demo\nname: {{ .Values.name }}\n")
+	writeFile(t, base, "workspaces/demo/clients/app.yaml", `id: c1
+client_name: app
+description: {{ .Values.client.description }}
+redirect_uris:
+{{- range .Values.client.redirect_uris }}
+  - {{ . }}
+{{- end }}
+`)
+	writeFile(t, overlay, "workspaces/demo/clients/app.yaml", "client_name: {{ .Values.name }}-app\n")
+
+	st, err := storage.InitMultiStorage(&storage.MultiStorageConfiguration{
+		DirPath:  []string{local, base},
+		Overlays: []string{overlay},
+		Values:   []string{prod},
+	}, storage.InitServerStorage)
+	require.NoError(t, err)
+
+	data, err := st.Read(context.Background(), api.WithWorkspace("demo"))
+	require.NoError(t, err)
+
+	// values.yaml of the first dir_path has the highest priority, configured values files override both
+	require.Equal(t, "local", data["name"])
+	require.Equal(t, map[string]any{
+		"c1": map[string]any{
+			"client_name":   "local-app",
+			"description":   "base",
+			"redirect_uris": []any{"https://app.example.com/callback"},
+		},
+	}, data["clients"])
+
+	t.Run("missing value", func(t *testing.T) {
+		writeFile(t, base, "workspaces/demo/clients/other.yaml", "id: c2\nclient_name: {{ .Values.missing }}\n")
+
+		_, err := st.Read(context.Background(), api.WithWorkspace("demo"))
+		require.ErrorContains(t, err, `map has no entry for key "missing"`)
+	})
+
+	t.Run("missing values file", func(t *testing.T) {
+		_, err := storage.InitMultiStorage(&storage.MultiStorageConfiguration{
+			DirPath: []string{base},
+			Values:  []string{filepath.Join(base, "missing.yaml")},
+		}, storage.InitServerStorage)
+		require.ErrorContains(t, err, "failed to read values file")
+	})
+}
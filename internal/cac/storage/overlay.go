@@ -0,0 +1,354 @@
+package storage
+
+import (
+	"bytes"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/templates"
+	"github.com/cloudentity/cac/internal/cac/utils"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/go-json-experiment/json"
+	ccyaml "github.com/goccy/go-yaml"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slog"
+)
+
+// JSONPatchSuffix marks overlay files containing RFC 6902 JSON patches, other overlay files are RFC 7396 merge patches
+const JSONPatchSuffix = ".jsonpatch"
+
+// Overlay is a patch of a single part of the configuration read from an overlay directory
+type Overlay struct {
+	// File is the path of the overlay file
+	File string
+	// Workspace is set for patches of a workspace read from a tenant storage
+	Workspace string
+	// Collection is empty for patches of the server or tenant itself
+	Collection string
+	// Entity is the id or the name of the patched entity, empty for single file collections
+	Entity string
+	// JSONPatch is true for RFC 6902 patches
+	JSONPatch bool
+	Patch     []byte
+}
+
+// ReadOverlays reads patches from the overlay directory, patches are returned in the order of file paths
+// files are laid out as in the storage, i.e. workspaces/<workspace>/clients/<client name>.yaml
+// when workspace is set, only patches of this workspace are returned, as they are applied to a server storage
+// patches are rendered as templates with the values
+func ReadOverlays(dir string, workspace string, values map[string]any) ([]Overlay, error) {
+	var (
+		files    []string
+		overlays []Overlay
+		err      error
+	)
+
+	if err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+			files = append(files, path)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to read overlay %s", dir)
+	}
+
+	sort.Strings(files)
+
+	for _, file := range files {
+		var (
+			rel     string
+			overlay = Overlay{File: file}
+			parts   []string
+		)
+
+		if rel, err = filepath.Rel(dir, file); err != nil {
+			return nil, err
+		}
+
+		parts = strings.Split(filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel))), "/")
+
+		if len(parts) >= 2 && parts[0] == "workspaces" {
+			overlay.Workspace, parts = parts[1], parts[2:]
+		}
+
+		if workspace != "" {
+			if overlay.Workspace != workspace {
+				slog.Debug("skipping overlay of another workspace", "file", file)
+				continue
+			}
+
+			overlay.Workspace = ""
+		}
+
+		if last := len(parts) - 1; last >= 0 && strings.HasSuffix(parts[last], JSONPatchSuffix) {
+			parts[last] = strings.TrimSuffix(parts[last], JSONPatchSuffix)
+			overlay.JSONPatch = true
+		}
+
+		switch len(parts) {
+		case 1:
+			overlay.Collection = collectionKey(parts[0])
+		case 2:
+			overlay.Collection, overlay.Entity = parts[0], parts[1]
+		default:
+			return nil, errors.Errorf("unsupported overlay file %s, expected [workspaces/<workspace>/]<collection>[/<entity>].yaml", file)
+		}
+
+		if overlay.Patch, err = templates.New(file, templates.WithValues(values)).Render(); err != nil {
+			return nil, errors.Wrapf(err, "failed to render overlay %s", file)
+		}
+
+		overlays = append(overlays, overlay)
+	}
+
+	return overlays, nil
+}
+
+// collectionKey returns the patch key of the single file collection, server and tenant files patch the root
+func collectionKey(file string) string {
+	if file == "server" || file == "tenant" {
+		return ""
+	}
+
+	for key, name := range collectionFiles {
+		if name == file {
+			return key
+		}
+	}
+
+	return file
+}
+
+// ApplyOverlays applies the patches to the data in order
+func ApplyOverlays(data models.Rfc7396PatchOperation, overlays []Overlay) (models.Rfc7396PatchOperation, error) {
+	var (
+		out map[string]any
+		err error
+	)
+
+	// patches operate on plain JSON values, as storages may return typed models
+	if err = convert(data, &out); err != nil {
+		return nil, errors.Wrap(err, "failed to convert data")
+	}
+
+	if out == nil {
+		out = map[string]any{}
+	}
+
+	for _, overlay := range overlays {
+		if err = applyOverlay(out, overlay); err != nil {
+			return nil, errors.Wrapf(err, "failed to apply overlay %s", overlay.File)
+		}
+	}
+
+	return out, nil
+}
+
+func applyOverlay(data map[string]any, overlay Overlay) error {
+	var (
+		parent = data
+		key    string
+		target any
+		ok     bool
+		err    error
+	)
+
+	if overlay.Workspace != "" {
+		if parent, ok = utils.AsMap(data["servers"])[overlay.Workspace].(map[string]any); !ok {
+			return errors.Errorf("workspace %s not found", overlay.Workspace)
+		}
+	}
+
+	if overlay.Collection == "" {
+		if target, err = patch(parent, overlay); err != nil {
+			return err
+		}
+
+		result, isMap := target.(map[string]any)
+
+		if !isMap {
+			return errors.New("patch must result in an object")
+		}
+
+		// merge patches modify the target in place, so the result is copied before the parent is replaced
+		result = maps.Clone(result)
+		clear(parent)
+		maps.Copy(parent, result)
+
+		return nil
+	}
+
+	key = overlay.Collection
+
+	if overlay.Entity != "" {
+		if parent, ok = parent[overlay.Collection].(map[string]any); !ok {
+			return errors.Errorf("collection %s not found", overlay.Collection)
+		}
+
+		if key, ok = findEntity(parent, overlay.Collection, overlay.Entity); !ok {
+			return errors.Errorf("entity %s not found in %s", overlay.Entity, overlay.Collection)
+		}
+	}
+
+	if target, err = patch(parent[key], overlay); err != nil {
+		return err
+	}
+
+	if target == nil {
+		delete(parent, key)
+		return nil
+	}
+
+	parent[key] = target
+
+	return nil
+}
+
+// findEntity returns the id of the entity with the given id or name, names are matched also as stored in file names
+func findEntity(collection map[string]any, name string, entity string) (string, bool) {
+	if _, ok := collection[entity]; ok {
+		return entity, true
+	}
+
+	ids := make([]string, 0, len(collection))
+
+	for id := range collection {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		var (
+			e        = utils.AsMap(collection[id])
+			fileName = strings.TrimSuffix(filepath.Base(EntityFile(name, id, e)), ".yaml")
+		)
+
+		if utils.EntityName(e) == entity || fileName == entity {
+			return id, true
+		}
+	}
+
+	return "", false
+}
+
+func patch(target any, overlay Overlay) (any, error) {
+	var (
+		bts []byte
+		out any
+		err error
+	)
+
+	// empty files are ignored, explicit null is required to delete the target
+	if len(bytes.TrimSpace(overlay.Patch)) == 0 {
+		return target, nil
+	}
+
+	if bts, err = ccyaml.YAMLToJSON(overlay.Patch); err != nil {
+		return nil, errors.Wrap(err, "failed to parse patch")
+	}
+
+	if !overlay.JSONPatch {
+		var p any
+
+		if err = json.Unmarshal(bts, &p); err != nil {
+			return nil, errors.Wrap(err, "failed to decode merge patch")
+		}
+
+		return MergePatch(target, p), nil
+	}
+
+	var (
+		ops jsonpatch.Patch
+		doc []byte
+	)
+
+	if ops, err = jsonpatch.DecodePatch(bts); err != nil {
+		return nil, errors.Wrap(err, "failed to decode json patch")
+	}
+
+	if target == nil {
+		target = map[string]any{}
+	}
+
+	if doc, err = json.Marshal(target); err != nil {
+		return nil, err
+	}
+
+	if doc, err = ops.Apply(doc); err != nil {
+		return nil, errors.Wrap(err, "failed to apply json patch")
+	}
+
+	if err = json.Unmarshal(doc, &out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// MergePatch applies the RFC 7396 merge patch to the target, null values delete fields
+func MergePatch(target any, patch any) any {
+	p, ok := patch.(map[string]any)
+
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]any)
+
+	if !ok {
+		t = map[string]any{}
+	}
+
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+
+		t[k] = MergePatch(t[k], v)
+	}
+
+	return t
+}
+
+func convert(in any, out any) error {
+	bts, err := json.Marshal(in)
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(bts, out)
+}
+
+// readOverlays reads patches of all overlay directories in order
+func readOverlays(dirs []string, workspace string, values map[string]any) ([]Overlay, error) {
+	var overlays []Overlay
+
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, errors.Wrapf(err, "failed to read overlay %s", dir)
+		}
+
+		o, err := ReadOverlays(dir, workspace, values)
+
+		if err != nil {
+			return nil, err
+		}
+
+		overlays = append(overlays, o...)
+	}
+
+	return overlays, nil
+}
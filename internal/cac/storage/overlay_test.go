@@ -0,0 +1,140 @@
+package storage_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/cloudentity/cac/internal/cac/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir string, path string, content string) {
+	path = filepath.Join(dir, path)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestStorageOverlays(t *testing.T) {
+	var (
+		base  = t.TempDir()
+		stage = t.TempDir()
+		prod  = t.TempDir()
+	)
+
+	writeFile(t, base, "workspaces/demo/server.yaml", "id: demo\nname: demo\naccess_token_ttl: 10m\n")
+	writeFile(t, base, "workspaces/demo/clients/Financroo.yaml", `id: c1
+client_name: Financroo
+description: base
+redirect_uris:
+  - http://localhost:8080/callback
+  - https://financroo.example.com/callback
+`)
+	writeFile(t, base, "workspaces/demo/clients/Debug_app.yaml", "id: c2\nclient_name: Debug app\n")
+	writeFile(t, base, "workspaces/demo/scopes.yaml", "openid:\n  description: openid\n")
+
+	writeFile(t, stage, "workspaces/demo/server.yaml", "name: stage\n")
+	writeFile(t, stage, "workspaces/demo/clients/Financroo.yaml", "description: stage\n")
+	writeFile(t, stage, "workspaces/other/server.yaml", "name: other\n")
+
+	// prod patches are applied on top of stage
+	writeFile(t, prod, "workspaces/demo/server.yaml", "name: prod\naccess_token_ttl: null\n")
+	writeFile(t, prod, "workspaces/demo/clients/Financroo.jsonpatch.yaml", `- op: remove
+  path: /redirect_uris/0
+- op: replace
+  path: /description
+  value: prod
+`)
+	writeFile(t, prod, "workspaces/demo/clients/Debug_app.yaml", "null\n")
+	writeFile(t, prod, "workspaces/demo/scopes.yaml", "openid:\n  description: prod\n")
+
+	st, err := storage.InitMultiStorage(&storage.MultiStorageConfiguration{
+		DirPath:  []string{base},
+		Overlays: []string{stage, prod},
+	}, storage.InitServerStorage)
+	require.NoError(t, err)
+
+	data, err := st.Read(context.Background(), api.WithWorkspace("demo"))
+	require.NoError(t, err)
+
+	require.Equal(t, "prod", data["name"])
+	require.NotContains(t, data, "access_token_ttl")
+	require.Equal(t, map[string]any{
+		"c1": map[string]any{
+			"client_name":   "Financroo",
+			"description":   "prod",
+			"redirect_uris": []any{"https://financroo.example.com/callback"},
+		},
+	}, data["clients"])
+	require.Equal(t, map[string]any{"openid": map[string]any{"description": "prod"}}, data["scopes_without_service"])
+
+	t.Run("filters are applied after overlays", func(t *testing.T) {
+		data, err := st.Read(context.Background(), api.WithWorkspace("demo"), api.WithFilters([]string{"scopes"}))
+		require.NoError(t, err)
+		require.Equal(t, []string{"scopes_without_service"}, keys(data))
+	})
+}
+
+func TestTenantStorageOverlays(t *testing.T) {
+	var (
+		base    = t.TempDir()
+		overlay = t.TempDir()
+	)
+
+	writeFile(t, base, "tenant.yaml", "name: base\n")
+	writeFile(t, base, "workspaces/demo/server.yaml", "id: demo\nname: demo\n")
+	writeFile(t, base, "workspaces/demo/clients/app.yaml", "id: c1\nclient_name: app\n")
+
+	writeFile(t, overlay, "tenant.yaml", "name: prod\n")
+	writeFile(t, overlay, "workspaces/demo/clients/c1.yaml", "description: prod\n")
+
+	st, err := storage.InitMultiStorage(&storage.MultiStorageConfiguration{
+		DirPath:  []string{base},
+		Overlays: []string{overlay},
+	}, storage.InitTenantStorage)
+	require.NoError(t, err)
+
+	data, err := st.Read(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, "prod", data["name"])
+	require.Equal(t, map[string]any{
+		"demo": map[string]any{
+			"name": "demo",
+			"clients": map[string]any{
+				"c1": map[string]any{"client_name": "app", "description": "prod"},
+			},
+		},
+	}, data["servers"])
+
+	t.Run("missing entity", func(t *testing.T) {
+		writeFile(t, overlay, "workspaces/demo/clients/missing.yaml", "description: prod\n")
+
+		_, err := st.Read(context.Background())
+		require.ErrorContains(t, err, "entity missing not found in clients")
+	})
+}
+
+func TestMergePatch(t *testing.T) {
+	require.Equal(t,
+		map[string]any{"a": "b", "c": map[string]any{"e": "f"}},
+		storage.MergePatch(
+			map[string]any{"a": "z", "c": map[string]any{"d": "x"}},
+			map[string]any{"a": "b", "c": map[string]any{"d": nil, "e": "f"}},
+		),
+	)
+	require.Equal(t, []any{"x"}, storage.MergePatch(map[string]any{"a": "b"}, []any{"x"}))
+	require.Nil(t, storage.MergePatch(map[string]any{"a": "b"}, nil))
+}
+
+func keys(m map[string]any) []string {
+	out := make([]string, 0, len(m))
+
+	for k := range m {
+		out = append(out, k)
+	}
+
+	return out
+}
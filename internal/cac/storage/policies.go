@@ -5,7 +5,7 @@ import (
 	"path/filepath"
 )
 
-func StorePolicies(policies models.TreePolicies, path string) error {
+func StorePolicies(policies models.TreePolicies, path string, opts ...ReadFileOpt) error {
 	for id, policy := range policies {
 		var (
 			sc   = NewWithID(id, policy)
@@ -30,7 +30,7 @@ func StorePolicies(policies models.TreePolicies, path string) error {
 			sc.Other.Definition = createMultilineIncludeTemplate(fname, 2)
 		}
 
-		if err = writeFile(sc, filepath.Join(path, name)); err != nil {
+		if err = writeFile(sc, filepath.Join(path, name), opts...); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,46 @@
+package storage_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/cloudentity/cac/internal/cac/provenance"
+	"github.com/cloudentity/cac/internal/cac/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageProvenance(t *testing.T) {
+	var (
+		base      = t.TempDir()
+		overlay   = t.TempDir()
+		positions = provenance.Map{}
+		write     = func(dir string, path string, content string) {
+			path = filepath.Join(dir, "workspaces", "demo", path)
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+			require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		}
+	)
+
+	write(base, "server.yaml", "id: demo\nname: demo\n")
+	write(base, "clients/one.yaml", "id: c1\nclient_name: one\ndescription: base\n")
+	write(overlay, "clients/one.yaml", "id: c1\n\nclient_name: one\n")
+	write(overlay, "scopes.yaml", "openid:\n  description: openid\n")
+
+	st, err := storage.InitMultiStorage(&storage.MultiStorageConfiguration{
+		DirPath: []string{overlay, base},
+	}, storage.InitServerStorage)
+	require.NoError(t, err)
+
+	_, err = st.Read(context.Background(), api.WithWorkspace("demo"), api.WithProvenance(positions))
+	require.NoError(t, err)
+
+	// overlay has the highest priority
+	require.Equal(t, provenance.Position{File: filepath.Join(overlay, "workspaces/demo/clients/one.yaml"), Line: 3, Column: 1}, positions["/clients/c1/client_name"])
+	require.Equal(t, provenance.Position{File: filepath.Join(base, "workspaces/demo/clients/one.yaml"), Line: 3, Column: 1}, positions["/clients/c1/description"])
+	require.Equal(t, provenance.Position{File: filepath.Join(overlay, "workspaces/demo/scopes.yaml"), Line: 2, Column: 3}, positions["/scopes_without_service/openid/description"])
+	require.Equal(t, provenance.Position{File: filepath.Join(base, "workspaces/demo/server.yaml"), Line: 2, Column: 1}, positions["/name"])
+	require.NotContains(t, positions, "/clients/c1/id")
+}
@@ -4,6 +4,9 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/cloudentity/cac/internal/cac/provenance"
+	"github.com/cloudentity/cac/internal/cac/secrets"
 	"github.com/cloudentity/cac/internal/cac/templates"
 	ccyaml "github.com/goccy/go-yaml"
 	"github.com/pkg/errors"
@@ -11,9 +14,77 @@ import (
 )
 
 type ReadFileOpts struct {
+	// Positions when set is filled with positions of read values
+	Positions provenance.Map
+	// Pointer is a JSON pointer of the file content in the configuration
+	Pointer string
+	// Values are exposed to templates as .Values
+	Values map[string]any
+	// Lookup resolves ids of entities referenced by names in templates
+	Lookup templates.Lookup
+	// Age when set decrypts encrypted values of read files
+	Age *secrets.AgeConfiguration
 }
 type ReadFileOpt func(opts *ReadFileOpts)
 
+// WithPositions records positions of read values in the map
+func WithPositions(positions provenance.Map) ReadFileOpt {
+	return func(opts *ReadFileOpts) {
+		opts.Positions = positions
+	}
+}
+
+// WithValues exposes values to templates of read files
+func WithValues(values map[string]any) ReadFileOpt {
+	return func(opts *ReadFileOpts) {
+		opts.Values = values
+	}
+}
+
+// WithLookup resolves ids of entities referenced by names in templates of read files
+func WithLookup(lookup templates.Lookup) ReadFileOpt {
+	return func(opts *ReadFileOpts) {
+		opts.Lookup = lookup
+	}
+}
+
+// WithAge decrypts encrypted values of read files with the age configuration
+func WithAge(age *secrets.AgeConfiguration) ReadFileOpt {
+	return func(opts *ReadFileOpts) {
+		opts.Age = age
+	}
+}
+
+// under places the file content under the key in the configuration
+func under(key string) ReadFileOpt {
+	return func(opts *ReadFileOpts) {
+		opts.Pointer = provenance.Join(opts.Pointer, key)
+	}
+}
+
+// positionOpts returns options recording positions when provenance is requested
+func positionOpts(positions provenance.Map) []ReadFileOpt {
+	if positions == nil {
+		return nil
+	}
+
+	return []ReadFileOpt{WithPositions(positions)}
+}
+
+// decryptOpts returns options decrypting read values, unless encrypted values are requested
+// without the age configuration values are decrypted with the identity file from the environment
+func decryptOpts(age *secrets.AgeConfiguration, options *api.Options) []ReadFileOpt {
+	if options.Encrypted {
+		return nil
+	}
+
+	if age == nil {
+		age = &secrets.AgeConfiguration{}
+	}
+
+	return []ReadFileOpt{WithAge(age)}
+}
+
 func readFile(path string, opts ...ReadFileOpt) (map[string]any, error) {
 	var (
 		o   = ReadFileOpts{}
@@ -32,7 +103,7 @@ func readFile(path string, opts ...ReadFileOpt) (map[string]any, error) {
 	
 	slog.Debug("reading file", "path", path)
 
-	if bts, err = templates.New(path).Render(); err != nil {
+	if bts, err = templates.New(path, templates.WithValues(o.Values), templates.WithLookup(o.Lookup)).Render(); err != nil {
 		if os.IsNotExist(err) {
 			slog.Debug("file not found", "path", path)
 			return out, nil
@@ -48,6 +119,23 @@ func readFile(path string, opts ...ReadFileOpt) (map[string]any, error) {
 		return out, errors.Wrapf(err, "failed to unmarshal template %s", path)
 	}
 
+	if o.Age != nil {
+		if err = o.Age.DecryptValues(out); err != nil {
+			return out, errors.Wrapf(err, "failed to decrypt values of %s", path)
+		}
+	}
+
+	if o.Positions != nil {
+		var positions provenance.Map
+
+		// positions refer to the rendered template, multiline includes shift lines which follow them
+		if positions, err = provenance.FromYAML(path, bts); err != nil {
+			return out, errors.Wrapf(err, "failed to parse positions of %s", path)
+		}
+
+		o.Positions.Merge(o.Pointer, positions)
+	}
+
 	slog.Debug("read yaml", "path", path, "out", out)
 
 	return out, nil
@@ -55,11 +143,16 @@ func readFile(path string, opts ...ReadFileOpt) (map[string]any, error) {
 
 func readFiles(path string, opts ...ReadFileOpt) (map[string]any, error) {
 	var (
+		o   = ReadFileOpts{}
 		out = map[string]any{}
 		dir []os.DirEntry
 		err error
 	)
 
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	if dir, err = os.ReadDir(path); err != nil {
 		if os.IsNotExist(err) {
 			return out, nil
@@ -72,9 +165,10 @@ func readFiles(path string, opts ...ReadFileOpt) (map[string]any, error) {
 		var (
 			name = file.Name()
 			ext  = filepath.Ext(name)
-			it   map[string]any
-			id   string
-			ok   bool
+			it        map[string]any
+			id        string
+			ok        bool
+			positions provenance.Map
 		)
 
 		if ext != ".yaml" && ext != ".yml" {
@@ -82,7 +176,12 @@ func readFiles(path string, opts ...ReadFileOpt) (map[string]any, error) {
 			continue
 		}
 
-		if it, err = readFile(filepath.Join(path, name)); err != nil {
+		if o.Positions != nil {
+			positions = provenance.Map{}
+		}
+
+		// entity id is known once the file is read, so positions are collected separately
+		if it, err = readFile(filepath.Join(path, name), append(positionOpts(positions), WithValues(o.Values), WithLookup(o.Lookup), WithAge(o.Age))...); err != nil {
 			return out, err
 		}
 
@@ -91,6 +190,11 @@ func readFiles(path string, opts ...ReadFileOpt) (map[string]any, error) {
 		}
 
 		delete(it, "id")
+		delete(positions, "/id")
+
+		if o.Positions != nil {
+			o.Positions.Merge(provenance.Join(o.Pointer, id), positions)
+		}
 
 		out[id] = it
 	}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudentity/cac/internal/cac/provenance"
+	"github.com/cloudentity/cac/internal/cac/templates"
+)
+
+// RenderFile renders the template file like it is rendered when the configuration is read
+// names of entities are resolved in the workspace, which defaults to the workspace of the file path
+func (m *MultiStorage) RenderFile(path string, workspace string) ([]byte, error) {
+	var opts = []templates.Opt{templates.WithValues(m.Values)}
+
+	if workspace == "" {
+		workspace = workspaceOf(path)
+	}
+
+	if workspace != "" {
+		s := &ServerStorage{Config: &Configuration{DirPath: m.Config.DirPath[0], Values: m.Values, Layers: m.Config.DirPath, Age: m.Config.Age}}
+		opts = append(opts, templates.WithLookup(s.lookup(workspace)))
+	}
+
+	return templates.New(path, opts...).Render()
+}
+
+// workspaceOf returns the workspace of the file laid out as in the storage, i.e. data/workspaces/demo/server.yaml
+func workspaceOf(path string) string {
+	var parts = strings.Split(filepath.ToSlash(path), "/")
+
+	for i := len(parts) - 3; i >= 0; i-- {
+		if parts[i] == "workspaces" {
+			return parts[i+1]
+		}
+	}
+
+	return ""
+}
+
+// Source is a file an entity is read from
+type Source struct {
+	// Layer is the dir_path the file belongs to
+	Layer string `json:"layer"`
+	File  string `json:"file"`
+}
+
+// Sources returns files of entities the positions were recorded for, keyed by pointers of entities, i.e. /clients/c1
+// files are listed in the priority order of layers, so the first file overrides values of the following ones
+func (m *MultiStorage) Sources(positions provenance.Map) map[string][]Source {
+	var (
+		out   = map[string][]Source{}
+		seen  = map[string]bool{}
+		order = map[string]int{}
+	)
+
+	for i, dir := range m.Config.DirPath {
+		order[dir] = i
+	}
+
+	for pointer, position := range positions {
+		var (
+			layer, rel = m.layerOf(position.File)
+			entity     string
+		)
+
+		if layer == "" {
+			continue
+		}
+
+		entity = entityPointer(pointer, rel)
+
+		if seen[entity+"\x00"+position.File] {
+			continue
+		}
+
+		seen[entity+"\x00"+position.File] = true
+		out[entity] = append(out[entity], Source{Layer: layer, File: position.File})
+	}
+
+	for _, sources := range out {
+		sort.Slice(sources, func(i, j int) bool {
+			if order[sources[i].Layer] != order[sources[j].Layer] {
+				return order[sources[i].Layer] < order[sources[j].Layer]
+			}
+
+			return sources[i].File < sources[j].File
+		})
+	}
+
+	return out
+}
+
+// layerOf returns the dir_path of the file and the path of the file relative to it
+func (m *MultiStorage) layerOf(file string) (string, string) {
+	var layer, rel string
+
+	for _, dir := range m.Config.DirPath {
+		r, err := filepath.Rel(dir, file)
+
+		if err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		// nested dir paths are matched by the closest one
+		if layer == "" || len(dir) > len(layer) {
+			layer, rel = dir, r
+		}
+	}
+
+	return layer, rel
+}
+
+// entityPointer truncates the pointer of a value to the pointer of the entity stored in the file
+// the file path relative to the layer has one part per pointer segment of the entity, i.e. clients/Financroo.yaml is /clients/c1,
+// except for server, tenant and theme files which hold fields of their parent
+func entityPointer(pointer string, rel string) string {
+	var (
+		parts    = strings.Split(filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel))), "/")
+		segments = strings.Split(pointer, "/")[1:]
+		depth    = len(parts)
+	)
+
+	switch parts[len(parts)-1] {
+	case "server", "tenant", "theme":
+		depth--
+	}
+
+	// a workspace read from a server storage is the root of pointers
+	if parts[0] == "workspaces" && (len(segments) == 0 || segments[0] != "servers") {
+		depth -= 2
+	}
+
+	if depth < 0 {
+		depth = 0
+	}
+
+	if depth < len(segments) {
+		segments = segments[:depth]
+	}
+
+	return "/" + strings.Join(segments, "/")
+}
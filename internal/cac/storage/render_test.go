@@ -0,0 +1,57 @@
+package storage_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/cloudentity/cac/internal/cac/provenance"
+	"github.com/cloudentity/cac/internal/cac/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageSources(t *testing.T) {
+	var (
+		dir       = t.TempDir()
+		base      = filepath.Join(dir, "base")
+		local     = filepath.Join(dir, "local")
+		positions = provenance.Map{}
+	)
+
+	writeFile(t, base, "workspaces/demo/server.yaml", "id: demo\nname: demo\n")
+	writeFile(t, base, "workspaces/demo/clients/app.yaml", "id: c1\nclient_name: app\ndescription: base\n")
+	writeFile(t, base, "workspaces/demo/scopes.yaml", "openid:\n  description: openid\n")
+	writeFile(t, base, "workspaces/demo/scripts/debug.yaml", "id: s1\nname: debug\n")
+	writeFile(t, local, "workspaces/demo/clients/app.yaml", "id: c1\nscript_id: {{ scriptID \"debug\" }}\n")
+
+	st, err := storage.InitMultiStorage(&storage.MultiStorageConfiguration{
+		DirPath: []string{local, base},
+	}, storage.InitServerStorage)
+	require.NoError(t, err)
+
+	_, err = st.Read(context.Background(), api.WithWorkspace("demo"), api.WithProvenance(positions))
+	require.NoError(t, err)
+
+	require.Equal(t, map[string][]storage.Source{
+		"/": {
+			{Layer: base, File: filepath.Join(base, "workspaces/demo/server.yaml")},
+		},
+		"/clients/c1": {
+			{Layer: local, File: filepath.Join(local, "workspaces/demo/clients/app.yaml")},
+			{Layer: base, File: filepath.Join(base, "workspaces/demo/clients/app.yaml")},
+		},
+		"/scopes_without_service": {
+			{Layer: base, File: filepath.Join(base, "workspaces/demo/scopes.yaml")},
+		},
+		"/scripts/s1": {
+			{Layer: base, File: filepath.Join(base, "workspaces/demo/scripts/debug.yaml")},
+		},
+	}, st.Sources(positions))
+
+	t.Run("render file", func(t *testing.T) {
+		bts, err := st.RenderFile(filepath.Join(local, "workspaces/demo/clients/app.yaml"), "")
+		require.NoError(t, err)
+		require.Equal(t, "id: c1\nscript_id: s1\n", string(bts))
+	})
+}
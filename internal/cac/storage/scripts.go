@@ -5,7 +5,7 @@ import (
 	"path/filepath"
 )
 
-func storeScripts(scripts models.TreeScripts, path string) error {
+func storeScripts(scripts models.TreeScripts, path string, opts ...ReadFileOpt) error {
 	for id, script := range scripts {
 		var (
 			sc   = NewWithID(id, script)
@@ -25,7 +25,7 @@ func storeScripts(scripts models.TreeScripts, path string) error {
 
 		sc.Other.Body = createMultilineIncludeTemplate(jsn, 2)
 
-		if err = writeFile(sc, filepath.Join(path, name)); err != nil {
+		if err = writeFile(sc, filepath.Join(path, name), opts...); err != nil {
 			return err
 		}
 	}
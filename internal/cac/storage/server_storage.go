@@ -7,6 +7,8 @@ import (
 	"github.com/cloudentity/acp-client-go/clients/hub/models"
 	smodels "github.com/cloudentity/acp-client-go/clients/system/models"
 	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/cloudentity/cac/internal/cac/secrets"
+	"github.com/cloudentity/cac/internal/cac/templates"
 	"github.com/cloudentity/cac/internal/cac/utils"
 	"github.com/pkg/errors"
 	"golang.org/x/exp/maps"
@@ -16,6 +18,12 @@ import (
 
 type Configuration struct {
 	DirPath string `json:"dir_path"`
+	// Values are exposed to templates of read files as .Values
+	Values map[string]any `json:"-"`
+	// Layers are dir paths of all storages in priority order, names of entities referenced by templates are resolved in all of them
+	Layers []string `json:"-"`
+	// Age decrypts encrypted values of read files
+	Age *secrets.AgeConfiguration `json:"-"`
 }
 
 var DefaultConfig = Configuration{
@@ -41,6 +49,7 @@ func (s *ServerStorage) Write(ctx context.Context, input models.Rfc7396PatchOper
 		workspace     string
 		data          *models.TreeServer
 		options       = &api.Options{}
+		fileOpts      []ReadFileOpt
 		err           error
 	)
 
@@ -58,95 +67,98 @@ func (s *ServerStorage) Write(ctx context.Context, input models.Rfc7396PatchOper
 		return errors.Wrap(err, "failed to convert patch to tree server")
 	}
 
-	if err = s.storeServer(workspace, data); err != nil {
+	// existing files are rendered with names resolved in the written data to keep their template expressions
+	fileOpts = []ReadFileOpt{WithValues(s.Config.Values), WithLookup(dataLookup(input))}
+
+	if err = s.storeServer(workspace, data, fileOpts...); err != nil {
 		return err
 	}
 
 	if err = writeFiles(data.Clients,
 		filepath.Join(workspacePath, "clients"),
-		func(id string, it models.TreeClient) string { return it.ClientName }); err != nil {
+		func(id string, it models.TreeClient) string { return it.ClientName }, fileOpts...); err != nil {
 		return err
 	}
 
 	if err = writeFiles(data.Idps,
 		filepath.Join(workspacePath, "idps"),
-		func(id string, it models.TreeIDP) string { return it.Name }); err != nil {
+		func(id string, it models.TreeIDP) string { return it.Name }, fileOpts...); err != nil {
 		return err
 	}
 
-	if err = writeFile(data.Claims, filepath.Join(workspacePath, "claims")); err != nil {
+	if err = writeFile(data.Claims, filepath.Join(workspacePath, "claims"), fileOpts...); err != nil {
 		return err
 	}
 
 	if err = writeFiles(data.CustomApps,
 		filepath.Join(workspacePath, "custom_apps"),
-		func(id string, it models.TreeCustomApp) string { return it.Name }); err != nil {
+		func(id string, it models.TreeCustomApp) string { return it.Name }, fileOpts...); err != nil {
 		return err
 	}
 
 	if err = writeFiles(data.Gateways,
 		filepath.Join(workspacePath, "gateways"),
-		func(id string, it models.TreeGateway) string { return it.Name }); err != nil {
+		func(id string, it models.TreeGateway) string { return it.Name }, fileOpts...); err != nil {
 		return err
 	}
 
-	if err = writeFile(data.PolicyExecutionPoints, filepath.Join(workspacePath, "policy_execution_points")); err != nil {
+	if err = writeFile(data.PolicyExecutionPoints, filepath.Join(workspacePath, "policy_execution_points"), fileOpts...); err != nil {
 		return err
 	}
 
 	if err = writeFiles(data.Pools,
 		filepath.Join(workspacePath, "pools"),
-		func(id string, it models.TreePool) string { return it.Name }); err != nil {
+		func(id string, it models.TreePool) string { return it.Name }, fileOpts...); err != nil {
 		return err
 	}
 
-	if err = writeFile(data.ScopesWithoutService, filepath.Join(workspacePath, "scopes")); err != nil {
+	if err = writeFile(data.ScopesWithoutService, filepath.Join(workspacePath, "scopes"), fileOpts...); err != nil {
 		return err
 	}
 
-	if err = writeFile(data.ScriptExecutionPoints, filepath.Join(workspacePath, "script_execution_points")); err != nil {
+	if err = writeFile(data.ScriptExecutionPoints, filepath.Join(workspacePath, "script_execution_points"), fileOpts...); err != nil {
 		return err
 	}
 
-	if err = writeFile(data.ServerConsent, filepath.Join(workspacePath, "consent")); err != nil {
+	if err = writeFile(data.ServerConsent, filepath.Join(workspacePath, "consent"), fileOpts...); err != nil {
 		return err
 	}
 
 	if len(data.ServersBindings) > 0 {
 		if err = writeFile(map[string]any{
 			"bindings": maps.Keys(data.ServersBindings),
-		}, filepath.Join(workspacePath, "servers_bindings")); err != nil {
+		}, filepath.Join(workspacePath, "servers_bindings"), fileOpts...); err != nil {
 			return err
 		}
 	}
 
 	if err = writeFiles(data.Services,
 		filepath.Join(workspacePath, "services"),
-		func(id string, it models.TreeService) string { return it.Name }); err != nil {
+		func(id string, it models.TreeService) string { return it.Name }, fileOpts...); err != nil {
 		return err
 	}
 
 	if data.ThemeBinding != nil && data.ThemeBinding.ThemeID != "" {
-		if err = writeFile(data.ThemeBinding, filepath.Join(workspacePath, "theme_binding")); err != nil {
+		if err = writeFile(data.ThemeBinding, filepath.Join(workspacePath, "theme_binding"), fileOpts...); err != nil {
 			return err
 		}
 	}
 
 	if err = writeFiles(data.Webhooks,
 		filepath.Join(workspacePath, "webhooks"),
-		func(id string, it models.TreeWebhook) string { return id }); err != nil {
+		func(id string, it models.TreeWebhook) string { return id }, fileOpts...); err != nil {
 		return err
 	}
 
-	if err = writeFile(data.CibaAuthenticationService, filepath.Join(workspacePath, "ciba")); err != nil {
+	if err = writeFile(data.CibaAuthenticationService, filepath.Join(workspacePath, "ciba"), fileOpts...); err != nil {
 		return err
 	}
 
-	if err = storeScripts(data.Scripts, filepath.Join(workspacePath, "scripts")); err != nil {
+	if err = storeScripts(data.Scripts, filepath.Join(workspacePath, "scripts"), fileOpts...); err != nil {
 		return err
 	}
 
-	if err = StorePolicies(data.Policies, filepath.Join(workspacePath, "policies")); err != nil {
+	if err = StorePolicies(data.Policies, filepath.Join(workspacePath, "policies"), fileOpts...); err != nil {
 		return err
 	}
 
@@ -160,6 +172,8 @@ func (s *ServerStorage) Read(ctx context.Context, opts ...api.SourceOpt) (models
 		path      string
 		workspace string
 		server    models.Rfc7396PatchOperation
+		fileOpts  []ReadFileOpt
+		lookup    templates.Lookup
 		options   = &api.Options{}
 		err       error
 	)
@@ -173,57 +187,60 @@ func (s *ServerStorage) Read(ctx context.Context, opts ...api.SourceOpt) (models
 	}
 
 	path = s.workspacePath(workspace)
+	lookup = s.lookup(workspace)
+	fileOpts = append(positionOpts(options.Provenance), WithValues(s.Config.Values), WithLookup(lookup))
+	fileOpts = append(fileOpts, decryptOpts(s.Config.Age, options)...)
 
-	if server, err = readFile(filepath.Join(path, "server")); err != nil {
+	if server, err = readFile(filepath.Join(path, "server"), fileOpts...); err != nil {
 		return server, err
 	}
 
-	if err = readFilesToMap(server, "clients", filepath.Join(path, "clients")); err != nil {
+	if err = readFilesToMap(server, "clients", filepath.Join(path, "clients"), fileOpts...); err != nil {
 		return nil, err
 	}
 
-	if err = readFilesToMap(server, "idps", filepath.Join(path, "idps")); err != nil {
+	if err = readFilesToMap(server, "idps", filepath.Join(path, "idps"), fileOpts...); err != nil {
 		return nil, err
 	}
 
-	if err = readFileToMap(server, "claims", filepath.Join(path, "claims")); err != nil {
+	if err = readFileToMap(server, "claims", filepath.Join(path, "claims"), fileOpts...); err != nil {
 		return nil, err
 	}
 
-	if err = readFilesToMap(server, "custom_apps", filepath.Join(path, "custom_apps")); err != nil {
+	if err = readFilesToMap(server, "custom_apps", filepath.Join(path, "custom_apps"), fileOpts...); err != nil {
 		return nil, err
 	}
 
-	if err = readFilesToMap(server, "gateways", filepath.Join(path, "gateways")); err != nil {
+	if err = readFilesToMap(server, "gateways", filepath.Join(path, "gateways"), fileOpts...); err != nil {
 		return nil, err
 	}
 
-	if err = readFileToMap(server, "policy_execution_points", filepath.Join(path, "policy_execution_points")); err != nil {
+	if err = readFileToMap(server, "policy_execution_points", filepath.Join(path, "policy_execution_points"), fileOpts...); err != nil {
 		return nil, err
 	}
 
-	if err = readFilesToMap(server, "pools", filepath.Join(path, "pools")); err != nil {
+	if err = readFilesToMap(server, "pools", filepath.Join(path, "pools"), fileOpts...); err != nil {
 		return nil, err
 	}
 
-	if err = readFileToMap(server, "scopes_without_service", filepath.Join(path, "scopes")); err != nil {
+	if err = readFileToMap(server, "scopes_without_service", filepath.Join(path, "scopes"), fileOpts...); err != nil {
 		return nil, err
 	}
 
-	if err = readFileToMap(server, "script_execution_points", filepath.Join(path, "script_execution_points")); err != nil {
+	if err = readFileToMap(server, "script_execution_points", filepath.Join(path, "script_execution_points"), fileOpts...); err != nil {
 		return nil, err
 	}
 
-	if err = readFileToMap(server, "server_consent", filepath.Join(path, "consent")); err != nil {
+	if err = readFileToMap(server, "server_consent", filepath.Join(path, "consent"), fileOpts...); err != nil {
 		return nil, err
 	}
 
-	if err = readFileToMap(server, "ciba_authentication_service", filepath.Join(path, "ciba")); err != nil {
+	if err = readFileToMap(server, "ciba_authentication_service", filepath.Join(path, "ciba"), fileOpts...); err != nil {
 		return nil, err
 	}
 
 	var sb map[string]any
-	if sb, err = readFile(filepath.Join(path, "servers_bindings")); err != nil {
+	if sb, err = readFile(filepath.Join(path, "servers_bindings"), WithValues(s.Config.Values), WithLookup(lookup)); err != nil {
 		return server, err
 	}
 
@@ -237,23 +254,23 @@ func (s *ServerStorage) Read(ctx context.Context, opts ...api.SourceOpt) (models
 		server["servers_bindings"] = binds
 	}
 
-	if err = readFilesToMap(server, "services", filepath.Join(path, "services")); err != nil {
+	if err = readFilesToMap(server, "services", filepath.Join(path, "services"), fileOpts...); err != nil {
 		return nil, err
 	}
 
-	if err = readFileToMap(server, "theme_binding", filepath.Join(path, "theme_binding")); err != nil {
+	if err = readFileToMap(server, "theme_binding", filepath.Join(path, "theme_binding"), fileOpts...); err != nil {
 		return nil, err
 	}
 
-	if err = readFilesToMap(server, "webhooks", filepath.Join(path, "webhooks")); err != nil {
+	if err = readFilesToMap(server, "webhooks", filepath.Join(path, "webhooks"), fileOpts...); err != nil {
 		return nil, err
 	}
 
-	if err = readFilesToMap(server, "scripts", filepath.Join(path, "scripts")); err != nil {
+	if err = readFilesToMap(server, "scripts", filepath.Join(path, "scripts"), fileOpts...); err != nil {
 		return nil, err
 	}
 
-	if err = readFilesToMap(server, "policies", filepath.Join(path, "policies")); err != nil {
+	if err = readFilesToMap(server, "policies", filepath.Join(path, "policies"), fileOpts...); err != nil {
 		return nil, err
 	}
 
@@ -272,7 +289,7 @@ func (s *ServerStorage) workspacePath(workspace string) string {
 	return filepath.Join(s.Config.DirPath, "workspaces", workspace)
 }
 
-func (s *ServerStorage) storeServer(workspace string, data *models.TreeServer) error {
+func (s *ServerStorage) storeServer(workspace string, data *models.TreeServer, opts ...ReadFileOpt) error {
 	var (
 		path   = filepath.Join(s.workspacePath(workspace), "server")
 		server smodels.ServerDump
@@ -291,7 +308,7 @@ func (s *ServerStorage) storeServer(workspace string, data *models.TreeServer) e
 
 	server.ID = workspace
 
-	if err = writeFile(server, path); err != nil {
+	if err = writeFile(server, path, opts...); err != nil {
 		return err
 	}
 
@@ -0,0 +1,281 @@
+package storage
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cloudentity/cac/internal/cac/provenance"
+	"github.com/cloudentity/cac/internal/cac/templates"
+	ccyaml "github.com/goccy/go-yaml"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slog"
+)
+
+var (
+	// valueLineRegexp splits a single line YAML value into the key or sequence item prefix and the value
+	valueLineRegexp = regexp.MustCompile(`^(\s*(?:-\s+)*(?:[^\s#{][^#{]*?:\s+)?)(\S.*?)\s*$`)
+	// controlActionRegexp matches template actions which do not render a value, i.e. range or end
+	controlActionRegexp = regexp.MustCompile(`\{\{-?\s*(if|else|end|range|with|define|block|template|break|continue)\b`)
+	// variableRegexp matches template variables, which are defined outside of the expression
+	variableRegexp = regexp.MustCompile(`\$\w`)
+)
+
+// keepTemplates restores template expressions of the existing file in the YAML about to be written
+//
+// Each single line value of the existing file which contains a template expression is rendered and compared
+// with the new value under the same pointer. When both are equal, the template expression replaces the value,
+// so only values which actually changed are rewritten. Values missing in the new YAML, i.e. secrets pulled
+// without --with-secrets, keep their template lines. The existing file must render, otherwise its templates would be lost.
+func keepTemplates(path string, bts []byte, opts ...ReadFileOpt) ([]byte, error) {
+	var (
+		o         = ReadFileOpts{}
+		tmpl      *templates.Template
+		src       []byte
+		rendered  []byte
+		positions provenance.Map
+		sources   provenance.Map
+		current   = map[string]any{}
+		written   = map[string]any{}
+		lines     []string
+		inserted  = map[int][]string{}
+		depth     int
+		err       error
+	)
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if src, err = os.ReadFile(path); err != nil || !strings.Contains(string(src), "{{") {
+		return bts, nil
+	}
+
+	tmpl = templates.New(path, templates.WithValues(o.Values), templates.WithLookup(o.Lookup))
+
+	if rendered, err = tmpl.RenderSource(src); err != nil {
+		return nil, errors.Wrapf(err, "failed to render existing file %s, fix or remove it to overwrite its templates", path)
+	}
+
+	if err = scalarsOf(rendered, current); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse existing file %s, fix or remove it to overwrite its templates", path)
+	}
+
+	if sources, err = provenance.FromYAML(path, rendered); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse existing file %s, fix or remove it to overwrite its templates", path)
+	}
+
+	if err = scalarsOf(bts, written); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse file %s", path)
+	}
+
+	if positions, err = provenance.FromYAML(path, bts); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse file %s", path)
+	}
+
+	lines = strings.Split(string(bts), "\n")
+
+	for i, line := range strings.Split(string(src), "\n") {
+		var (
+			match   = valueLineRegexp.FindStringSubmatch(line)
+			nested  = depth > 0
+			pointer string
+			value   any
+			ok      bool
+		)
+
+		// lines in control structures, i.e. range, are not rendered once in place, so they cannot be kept
+		for _, action := range controlActionRegexp.FindAllStringSubmatch(line, -1) {
+			switch action[1] {
+			case "if", "range", "with", "define", "block":
+				depth++
+			case "end":
+				depth--
+			}
+
+			nested = true
+		}
+
+		if nested || match == nil || strings.TrimSpace(match[1]) == "" || !strings.Contains(match[2], "{{") || variableRegexp.MatchString(match[2]) {
+			continue
+		}
+
+		if pointer, ok = templatePointer(tmpl, src, i, match); !ok {
+			continue
+		}
+
+		if _, ok = positions[pointer]; !ok {
+			if at, missing, ok := missingLine(pointer, match, lines, positions, sources); ok {
+				inserted[at] = append(inserted[at], missing)
+			} else {
+				slog.Warn("failed to keep template of a value missing in the written file", "path", path, "pointer", pointer)
+			}
+
+			continue
+		}
+
+		if value, ok = written[pointer]; !ok || !reflect.DeepEqual(value, current[pointer]) {
+			continue
+		}
+
+		if s, isString := value.(string); isString && strings.Contains(s, "\n") {
+			continue
+		}
+
+		if pos, ok := positions.Lookup(pointer); ok && pos.Line <= len(lines) {
+			if m := valueLineRegexp.FindStringSubmatch(lines[pos.Line-1]); m != nil && strings.TrimSpace(m[1]) != "" {
+				lines[pos.Line-1] = m[1] + match[2]
+			}
+		}
+	}
+
+	// lines are inserted from the end, so indexes of the remaining insertions stay valid
+	for _, at := range slices.Backward(slices.Sorted(maps.Keys(inserted))) {
+		lines = slices.Insert(lines, at, inserted[at]...)
+	}
+
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// missingLine returns the index of the written line before which the template line of the value missing in the written file
+// is inserted, and the line indented like the written siblings, so the value follows the same sibling as in the existing file
+func missingLine(pointer string, match []string, lines []string, positions provenance.Map, sources provenance.Map) (int, string, bool) {
+	var (
+		parent     = pointer[:strings.LastIndex(pointer, "/")]
+		key        = strings.TrimLeft(match[1], " ")
+		line       = sources[pointer].Line
+		prev, next string
+	)
+
+	// sequence items cannot be inserted, as they would shift other items
+	if strings.HasPrefix(key, "-") {
+		return 0, "", false
+	}
+
+	for p := range positions {
+		if p == pointer || !strings.HasPrefix(p, parent+"/") || strings.Contains(p[len(parent)+1:], "/") {
+			continue
+		}
+
+		source, ok := sources[p]
+
+		if !ok {
+			continue
+		}
+
+		if source.Line > line && (next == "" || source.Line < sources[next].Line) {
+			next = p
+		}
+
+		if source.Line < line && (prev == "" || source.Line > sources[prev].Line) {
+			prev = p
+		}
+	}
+
+	if pos, ok := positions[prev]; ok && pos.Line <= len(lines) {
+		return blockEnd(lines, pos.Line-1, pos.Column-1), strings.Repeat(" ", pos.Column-1) + key + match[2], true
+	}
+
+	// the first value is inserted before the following sibling, when the sibling key starts its line
+	if pos, ok := positions[next]; ok && pos.Line <= len(lines) && strings.TrimSpace(lines[pos.Line-1][:pos.Column-1]) == "" {
+		return pos.Line - 1, strings.Repeat(" ", pos.Column-1) + key + match[2], true
+	}
+
+	return 0, "", false
+}
+
+// blockEnd returns the index of the line following the value of the key at the line, nested lines are more indented
+// and sequence items may be indented like the key
+func blockEnd(lines []string, line int, indent int) int {
+	var i = line + 1
+
+	for ; i < len(lines); i++ {
+		var (
+			trimmed = strings.TrimLeft(lines[i], " ")
+			current = len(lines[i]) - len(trimmed)
+		)
+
+		if trimmed == "" || current > indent || current == indent && strings.HasPrefix(trimmed, "- ") {
+			continue
+		}
+
+		break
+	}
+
+	for i > line+1 && strings.TrimSpace(lines[i-1]) == "" {
+		i--
+	}
+
+	return i
+}
+
+// templatePointer returns the pointer of the value rendered by the template expression of the source line
+// the value is replaced with a sentinel, so the pointer is found in the rendered document
+func templatePointer(tmpl *templates.Template, src []byte, line int, match []string) (string, bool) {
+	var (
+		lines    = strings.Split(string(src), "\n")
+		sentinel = fmt.Sprintf("cac-template-%d", line)
+		rendered []byte
+		values   = map[string]any{}
+		pointer  string
+		err      error
+	)
+
+	lines[line] = match[1] + sentinel
+
+	if rendered, err = tmpl.RenderSource([]byte(strings.Join(lines, "\n"))); err != nil {
+		return "", false
+	}
+
+	if err = scalarsOf(rendered, values); err != nil {
+		return "", false
+	}
+
+	for p, v := range values {
+		if v != sentinel {
+			continue
+		}
+
+		// values rendered more than once, i.e. in a range, cannot be traced back to a single expression
+		if pointer != "" {
+			return "", false
+		}
+
+		pointer = p
+	}
+
+	return pointer, pointer != ""
+}
+
+func scalarsOf(bts []byte, out map[string]any) error {
+	var value any
+
+	if err := ccyaml.Unmarshal(bts, &value); err != nil {
+		return err
+	}
+
+	scalars(value, "", out)
+
+	return nil
+}
+
+// scalars collects leaf values by their pointers
+func scalars(value any, pointer string, out map[string]any) {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, it := range v {
+			scalars(it, provenance.Join(pointer, k), out)
+		}
+	case []any:
+		for i, it := range v {
+			scalars(it, provenance.Join(pointer, strconv.Itoa(i)), out)
+		}
+	default:
+		out[pointer] = v
+	}
+}
@@ -0,0 +1,149 @@
+package storage_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/api"
+	"github.com/cloudentity/cac/internal/cac/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteKeepsTemplates(t *testing.T) {
+	var (
+		dir = t.TempDir()
+		st  = storage.InitServerStorage(&storage.Configuration{
+			DirPath: dir,
+			Values:  map[string]any{"name": "app", "uri": "https://app.example.com/callback", "scopes": []any{"openid"}},
+		})
+		data = models.Rfc7396PatchOperation{
+			"clients": map[string]any{
+				"c1": map[string]any{
+					"client_name":   "app",
+					"description":   "changed",
+					"client_secret": "s3cr3t",
+					"redirect_uris": []any{"https://app.example.com/callback", "http://localhost/callback"},
+					"scopes":        []any{"openid"},
+				},
+			},
+		}
+	)
+
+	t.Setenv("CAC_TEST_SECRET", "s3cr3t")
+
+	writeFile(t, dir, "workspaces/demo/clients/app.yaml", `id: c1
+client_name: {{ .Values.name }}
+client_secret: {{ env "CAC_TEST_SECRET" }}
+description: {{ .Values.name }}
+redirect_uris:
+  - {{ .Values.uri }}
+  - http://localhost/callback
+scopes:
+{{- range .Values.scopes }}
+  - {{ . }}
+{{- end }}
+`)
+
+	require.NoError(t, st.Write(context.Background(), data, api.WithWorkspace("demo")))
+
+	bts, err := os.ReadFile(filepath.Join(dir, "workspaces", "demo", "clients", "app.yaml"))
+	require.NoError(t, err)
+
+	require.Contains(t, string(bts), "client_name: {{ .Values.name }}\n")
+	require.Contains(t, string(bts), `client_secret: {{ env "CAC_TEST_SECRET" }}`+"\n")
+	require.Contains(t, string(bts), "description: changed\n")
+	require.Contains(t, string(bts), "- {{ .Values.uri }}\n")
+	require.Contains(t, string(bts), "- http://localhost/callback\n")
+	require.Contains(t, string(bts), "- openid\n")
+
+	read, err := st.Read(context.Background(), api.WithWorkspace("demo"))
+	require.NoError(t, err)
+
+	client := read["clients"].(map[string]any)["c1"].(map[string]any)
+	require.Equal(t, "app", client["client_name"])
+	require.Equal(t, "s3cr3t", client["client_secret"])
+	require.Equal(t, []any{"https://app.example.com/callback", "http://localhost/callback"}, client["redirect_uris"])
+
+	t.Run("changed value replaces template", func(t *testing.T) {
+		data["clients"].(map[string]any)["c1"].(map[string]any)["redirect_uris"] = []any{"https://changed.example.com/callback"}
+
+		require.NoError(t, st.Write(context.Background(), data, api.WithWorkspace("demo")))
+
+		bts, err := os.ReadFile(filepath.Join(dir, "workspaces", "demo", "clients", "app.yaml"))
+		require.NoError(t, err)
+		require.Contains(t, string(bts), "client_name: {{ .Values.name }}\n")
+		require.Contains(t, string(bts), "- https://changed.example.com/callback\n")
+		require.NotContains(t, string(bts), ".Values.uri")
+	})
+}
+
+func TestWriteKeepsTemplatesOfMissingValues(t *testing.T) {
+	var (
+		dir = t.TempDir()
+		st  = storage.InitServerStorage(&storage.Configuration{
+			DirPath: dir,
+			Values:  map[string]any{"name": "app"},
+		})
+		// remote configuration pulled without secrets
+		data = models.Rfc7396PatchOperation{
+			"clients": map[string]any{
+				"c1": map[string]any{
+					"client_name":   "app",
+					"redirect_uris": []any{"https://app.example.com/callback"},
+				},
+			},
+		}
+		path = filepath.Join(dir, "workspaces", "demo", "clients", "app.yaml")
+	)
+
+	t.Setenv("CAC_TEST_SECRET", "s3cr3t")
+	t.Setenv("CAC_TEST_TOKEN", "t0k3n")
+
+	writeFile(t, dir, "workspaces/demo/clients/app.yaml", `id: c1
+client_name: {{ .Values.name }}
+client_secret: {{ env "CAC_TEST_SECRET" }}
+redirect_uris:
+  - https://app.example.com/callback
+registration_access_token: {{ env "CAC_TEST_TOKEN" }}
+`)
+
+	require.NoError(t, st.Write(context.Background(), data, api.WithWorkspace("demo")))
+
+	bts, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(bts), "client_name: {{ .Values.name }}\nclient_secret: {{ env \"CAC_TEST_SECRET\" }}\n")
+	require.Contains(t, string(bts), "- https://app.example.com/callback\nregistration_access_token: {{ env \"CAC_TEST_TOKEN\" }}\n")
+
+	read, err := st.Read(context.Background(), api.WithWorkspace("demo"))
+	require.NoError(t, err)
+
+	client := read["clients"].(map[string]any)["c1"].(map[string]any)
+	require.Equal(t, "s3cr3t", client["client_secret"])
+	require.Equal(t, "t0k3n", client["registration_access_token"])
+	require.Equal(t, []any{"https://app.example.com/callback"}, client["redirect_uris"])
+}
+
+func TestWriteFailsOnInvalidExistingTemplate(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		st   = storage.InitServerStorage(&storage.Configuration{DirPath: dir})
+		data = models.Rfc7396PatchOperation{
+			"clients": map[string]any{
+				"c1": map[string]any{"client_name": "app"},
+			},
+		}
+		existing = "id: c1\nclient_name: {{ .Values.name\n"
+	)
+
+	writeFile(t, dir, "workspaces/demo/clients/app.yaml", existing)
+
+	err := st.Write(context.Background(), data, api.WithWorkspace("demo"))
+	require.ErrorContains(t, err, "failed to render existing file")
+
+	bts, err := os.ReadFile(filepath.Join(dir, "workspaces", "demo", "clients", "app.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, existing, string(bts))
+}
@@ -4,6 +4,7 @@ import (
     "context"
     "github.com/cloudentity/acp-client-go/clients/hub/models"
     "github.com/cloudentity/cac/internal/cac/api"
+    "github.com/cloudentity/cac/internal/cac/provenance"
     "github.com/cloudentity/cac/internal/cac/utils"
     "path/filepath"
 )
@@ -33,19 +34,19 @@ func (t *TenantStorage) Write(ctx context.Context, data models.Rfc7396PatchOpera
 
     if err = writeFiles(model.Pools,
         filepath.Join(path, "pools"),
-        func(id string, it models.TreePool) string { return it.Name }); err != nil {
+        func(id string, it models.TreePool) string { return it.Name }, WithValues(t.Config.Values)); err != nil {
         return err
     }
 
     if err = writeFiles(model.Schemas,
         filepath.Join(path, "schemas"),
-        func(id string, it models.TreeSchema) string { return it.Name }); err != nil {
+        func(id string, it models.TreeSchema) string { return it.Name }, WithValues(t.Config.Values)); err != nil {
         return err
     }
 
     if err = writeFiles(model.MfaMethods,
         filepath.Join(path, "mfa_methods"),
-        func(id string, it models.TreeMFAMethod) string { return it.Mechanism }); err != nil {
+        func(id string, it models.TreeMFAMethod) string { return it.Mechanism }, WithValues(t.Config.Values)); err != nil {
         return err
     }
 
@@ -61,11 +62,11 @@ func (t *TenantStorage) Write(ctx context.Context, data models.Rfc7396PatchOpera
 
         delete(themeConfig, "templates")
 
-        if err = writeFile(themeConfig, filepath.Join(themePath, "theme")); err != nil {
+        if err = writeFile(themeConfig, filepath.Join(themePath, "theme"), WithValues(t.Config.Values)); err != nil {
             return err
         }
 
-        if err = storeTemplates(theme.Templates, filepath.Join(themePath, "templates")); err != nil {
+        if err = storeTemplates(theme.Templates, filepath.Join(themePath, "templates"), WithValues(t.Config.Values)); err != nil {
             return err
         }
     }
@@ -92,6 +93,7 @@ func (t *TenantStorage) Read(ctx context.Context, opts ...api.SourceOpt) (models
         options    = &api.Options{}
         themeDirs  []string
         workspaces []string
+        fileOpts   []ReadFileOpt
         err        error
     )
 
@@ -99,23 +101,26 @@ func (t *TenantStorage) Read(ctx context.Context, opts ...api.SourceOpt) (models
         opt(options)
     }
 
-    if tenant, err = readFile(filepath.Join(path, "tenant")); err != nil {
+    fileOpts = append(positionOpts(options.Provenance), WithValues(t.Config.Values))
+    fileOpts = append(fileOpts, decryptOpts(t.Config.Age, options)...)
+
+    if tenant, err = readFile(filepath.Join(path, "tenant"), fileOpts...); err != nil {
         return nil, err
     }
 
-    if err = readFilesToMap(tenant, "pools", filepath.Join(path, "pools")); err != nil {
+    if err = readFilesToMap(tenant, "pools", filepath.Join(path, "pools"), fileOpts...); err != nil {
         return nil, err
     }
 
-    if err = readFilesToMap(tenant, "schemas", filepath.Join(path, "schemas")); err != nil {
+    if err = readFilesToMap(tenant, "schemas", filepath.Join(path, "schemas"), fileOpts...); err != nil {
         return nil, err
     }
 
-    if err = readFilesToMap(tenant, "mfa_methods", filepath.Join(path, "mfa_methods")); err != nil {
+    if err = readFilesToMap(tenant, "mfa_methods", filepath.Join(path, "mfa_methods"), fileOpts...); err != nil {
         return nil, err
     }
 
-    if err = readFilesToMap(tenant, "themes", filepath.Join(path, "themes")); err != nil {
+    if err = readFilesToMap(tenant, "themes", filepath.Join(path, "themes"), fileOpts...); err != nil {
         return nil, err
     }
 
@@ -129,9 +134,14 @@ func (t *TenantStorage) Read(ctx context.Context, opts ...api.SourceOpt) (models
         var (
             themeConfig map[string]any
             theme       *models.TreeTheme
+            positions   provenance.Map
         )
 
-        if themeConfig, err = readFile(filepath.Join(path, "themes", dir, "theme")); err != nil {
+        if options.Provenance != nil {
+            positions = provenance.Map{}
+        }
+
+        if themeConfig, err = readFile(filepath.Join(path, "themes", dir, "theme"), append(positionOpts(positions), WithValues(t.Config.Values))...); err != nil {
             return nil, err
         }
 
@@ -144,7 +154,7 @@ func (t *TenantStorage) Read(ctx context.Context, opts ...api.SourceOpt) (models
             templatesConfig map[string]any
         )
 
-        if templatesConfig, err = readFiles(filepath.Join(path, "themes", dir, "templates")); err != nil {
+        if templatesConfig, err = readFiles(filepath.Join(path, "themes", dir, "templates"), WithValues(t.Config.Values)); err != nil {
             return nil, err
         }
 
@@ -154,6 +164,10 @@ func (t *TenantStorage) Read(ctx context.Context, opts ...api.SourceOpt) (models
 
         theme.Templates = *templates
         themes[themeConfig["name"].(string)] = *theme
+
+        if options.Provenance != nil {
+            options.Provenance.Merge(provenance.Join("/themes", themeConfig["name"].(string)), positions)
+        }
     }
 
     if len(themes) > 0 {
@@ -168,10 +182,19 @@ func (t *TenantStorage) Read(ctx context.Context, opts ...api.SourceOpt) (models
         var servers = map[string]any{}
 
         for _, workspace := range workspaces {
-            var workspaceConfig models.Rfc7396PatchOperation
+            var (
+                workspaceConfig models.Rfc7396PatchOperation
+                positions       provenance.Map
+            )
 
             opts = append(opts, api.WithWorkspace(workspace), api.WithFilters([]string{}))
 
+            // workspace id is known once the workspace is read, so positions are collected separately
+            if options.Provenance != nil {
+                positions = provenance.Map{}
+                opts = append(opts, api.WithProvenance(positions))
+            }
+
             if workspaceConfig, err = t.ServerStorage.Read(ctx, opts...); err != nil {
                 return nil, err
             }
@@ -180,6 +203,12 @@ func (t *TenantStorage) Read(ctx context.Context, opts ...api.SourceOpt) (models
             delete(workspaceConfig, "id")
             delete(workspaceConfig, "tenant_id")
             servers[id] = workspaceConfig
+
+            if options.Provenance != nil {
+                delete(positions, "/id")
+                delete(positions, "/tenant_id")
+                options.Provenance.Merge(provenance.Join("/servers", id), positions)
+            }
         }
 
         tenant["servers"] = servers
@@ -194,7 +223,7 @@ func (t *TenantStorage) Read(ctx context.Context, opts ...api.SourceOpt) (models
 
 var _ Storage = &TenantStorage{}
 
-func storeTemplates(templates models.TreeTemplates, path string) error {
+func storeTemplates(templates models.TreeTemplates, path string, opts ...ReadFileOpt) error {
     for id, template := range templates {
         var (
             sc   = NewWithID(id, template)
@@ -213,7 +242,7 @@ func storeTemplates(templates models.TreeTemplates, path string) error {
 
         sc.Other.Content = createMultilineIncludeTemplate(name, 2)
 
-        if err = writeFile(sc, filepath.Join(path, name)); err != nil {
+        if err = writeFile(sc, filepath.Join(path, name), opts...); err != nil {
             return err
         }
     }
@@ -2,10 +2,10 @@ package storage
 
 import "github.com/cloudentity/acp-client-go/clients/hub/models"
 
-func readFileToMap(server models.Rfc7396PatchOperation, key string, path string) error {
+func readFileToMap(server models.Rfc7396PatchOperation, key string, path string, opts ...ReadFileOpt) error {
 	var err error
 
-	if server[key], err = readFile(path); err != nil {
+	if server[key], err = readFile(path, append(opts, under(key))...); err != nil {
 		return err
 	}
 
@@ -16,10 +16,10 @@ func readFileToMap(server models.Rfc7396PatchOperation, key string, path string)
 	return nil
 }
 
-func readFilesToMap(server models.Rfc7396PatchOperation, key string, path string) error {
+func readFilesToMap(server models.Rfc7396PatchOperation, key string, path string, opts ...ReadFileOpt) error {
 	var err error
 
-	if server[key], err = readFiles(path); err != nil {
+	if server[key], err = readFiles(path, append(opts, under(key))...); err != nil {
 		return err
 	}
 
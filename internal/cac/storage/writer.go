@@ -15,7 +15,7 @@ import (
 type Writer[T any] func(name string, it T) error
 type FileNameProvider[T any] func(id string, it T) string
 
-func writeFiles[T any](data map[string]T, parent string, fileName FileNameProvider[T]) error {
+func writeFiles[T any](data map[string]T, parent string, fileName FileNameProvider[T], opts ...ReadFileOpt) error {
 	var (
 		writer Writer[*WithID[T]]
 		names  = map[string]int{}
@@ -26,7 +26,7 @@ func writeFiles[T any](data map[string]T, parent string, fileName FileNameProvid
 		return nil
 	}
 
-	if writer, err = YAMLWriter[*WithID[T]](parent); err != nil {
+	if writer, err = YAMLWriter[*WithID[T]](parent, opts...); err != nil {
 		return err
 	}
 
@@ -55,7 +55,7 @@ func writeFiles[T any](data map[string]T, parent string, fileName FileNameProvid
 	return nil
 }
 
-func writeFile[T any](data T, path string) error {
+func writeFile[T any](data T, path string, opts ...ReadFileOpt) error {
 	var (
 		parent = filepath.Dir(path)
 		writer Writer[T]
@@ -67,7 +67,7 @@ func writeFile[T any](data T, path string) error {
 		return nil
 	}
 
-	if writer, err = YAMLWriter[T](parent); err != nil {
+	if writer, err = YAMLWriter[T](parent, opts...); err != nil {
 		return err
 	}
 
@@ -78,7 +78,9 @@ func writeFile[T any](data T, path string) error {
 	return nil
 }
 
-func YAMLWriter[T any](dirPath string) (Writer[T], error) {
+// YAMLWriter writes items as YAML files, template expressions of existing files are kept for unchanged values
+// existing files are rendered with the read options, i.e. values
+func YAMLWriter[T any](dirPath string, opts ...ReadFileOpt) (Writer[T], error) {
 	var (
 		raw Writer[[]byte]
 		err error
@@ -99,6 +101,10 @@ func YAMLWriter[T any](dirPath string) (Writer[T], error) {
 			return err
 		}
 
+		if bts, err = keepTemplates(filepath.Join(dirPath, normalize(name)), bts, opts...); err != nil {
+			return err
+		}
+
 		bts = postProcessMultilineTemplates(bts)
 
 		if err = raw(name, bts); err != nil {
@@ -168,9 +174,17 @@ func createMultilineIncludeTemplate(str string, indent int) string {
 	return fmt.Sprintf(`⌘⌘%d include "%s"⌘⌘`, indent, str)
 }
 
+// InlineTemplate creates a template that will be replaced by the template expression in a post-processing step
+// the expression must render a valid YAML scalar, as it is written without quotes
+func InlineTemplate(expr string) string {
+	return fmt.Sprintf(`⌘⌘⌘%s⌘⌘⌘`, expr)
+}
+
 var multilineTemplateRegexp = regexp.MustCompile(`⌘⌘(\d+) ([^⌘]+)⌘⌘`)
+var inlineTemplateRegexp = regexp.MustCompile(`⌘⌘⌘([^⌘]+)⌘⌘⌘`)
 
 func postProcessMultilineTemplates(bts []byte) []byte {
+	bts = inlineTemplateRegexp.ReplaceAll(bts, []byte("{{ $1 }}"))
 	bts = multilineTemplateRegexp.ReplaceAll(bts, []byte("{{ $2 | nindent $1 }}"))
 
 	return bts
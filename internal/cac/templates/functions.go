@@ -8,6 +8,7 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/cloudentity/cac/internal/cac/secrets"
 	zb32 "github.com/corvus-ch/zbase32"
 	"github.com/pkg/errors"
 
@@ -21,6 +22,11 @@ func functions(t *Template) template.FuncMap {
 	funcMap["nindent"] = nindent
 	funcMap["zbase32"] = zbase32
 	funcMap["apiID"] = apiID
+	funcMap["secret"] = secrets.Resolve
+	funcMap["policyID"] = lookup(t, "policies")
+	funcMap["scriptID"] = lookup(t, "scripts")
+	funcMap["serviceID"] = lookup(t, "services")
+	funcMap["clientID"] = lookup(t, "clients")
 	return funcMap
 }
 
@@ -50,6 +56,19 @@ func include(t *Template) func(string) (string, error) {
 
 var ErrEnvNotFound = errors.New("environment variable not found")
 
+var ErrEntityNotFound = errors.New("entity not found")
+
+// lookup returns a function resolving ids of entities in the collection by names
+func lookup(t *Template, collection string) func(string) (string, error) {
+	return func(name string) (string, error) {
+		if t.Lookup == nil {
+			return "", errors.Errorf("%s names can be resolved only in workspace configuration files", collection)
+		}
+
+		return t.Lookup(collection, name)
+	}
+}
+
 func env(key string) (any, error) {
 	env := os.Getenv(key)
 
@@ -116,3 +116,28 @@ func TestFunctions(t *testing.T) {
 		})
 	}
 }
+
+func TestLookupFunctions(t *testing.T) {
+	var (
+		path   = filepath.Join(t.TempDir(), "test.yaml")
+		lookup = func(collection string, name string) (string, error) {
+			return collection + "/" + name, nil
+		}
+	)
+
+	err := os.WriteFile(path, []byte(`policy_id: {{ policyID "MFA_User" }}
+script_id: {{ scriptID "debug" }}
+service_id: {{ serviceID "OAuth2" }}
+client_id: {{ clientID "Financroo" }}`), 0644)
+	require.NoError(t, err)
+
+	outBts, err := templates.New(path, templates.WithLookup(lookup)).Render()
+	require.NoError(t, err)
+	require.Equal(t, `policy_id: policies/MFA_User
+script_id: scripts/debug
+service_id: services/OAuth2
+client_id: clients/Financroo`, string(outBts))
+
+	_, err = templates.New(path).Render()
+	require.ErrorContains(t, err, "policies names can be resolved only in workspace configuration files")
+}
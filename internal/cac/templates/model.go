@@ -9,27 +9,71 @@ import (
 
 type Template struct {
 	Path string
+	// Values are exposed to the template as .Values
+	Values map[string]any
+	// Lookup resolves ids of entities referenced by names, i.e. with policyID
+	Lookup Lookup
 }
 
-func New(path string) *Template {
-	return &Template{Path: path}
+// Lookup returns the id of the entity with the name in the collection, i.e. policies
+type Lookup func(collection string, name string) (string, error)
+
+type Opt func(t *Template)
+
+// WithValues exposes values to the template as .Values
+func WithValues(values map[string]any) Opt {
+	return func(t *Template) {
+		t.Values = values
+	}
+}
+
+// WithLookup resolves ids of entities referenced by names with the lookup
+func WithLookup(lookup Lookup) Opt {
+	return func(t *Template) {
+		t.Lookup = lookup
+	}
+}
+
+func New(path string, opts ...Opt) *Template {
+	t := &Template{Path: path}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	if t.Values == nil {
+		t.Values = map[string]any{}
+	}
+
+	return t
 }
 
 func (t *Template) Render() ([]byte, error) {
 	var (
-		buff = bytes.Buffer{}
-		tmpl *template.Template
-		bts  []byte
-		err  error
+		bts []byte
+		err error
 	)
 
 	if bts, err = os.ReadFile(t.Path); err != nil {
 		return nil, err
 	}
 
+	return t.RenderSource(bts)
+}
+
+// RenderSource renders the source in place of the template file content, includes are resolved relative to the file
+func (t *Template) RenderSource(bts []byte) ([]byte, error) {
+	var (
+		buff = bytes.Buffer{}
+		tmpl *template.Template
+		err  error
+	)
+
 	slog.Debug("rendering template", "path", t.Path, "data", string(bts))
 
-	if tmpl, err = template.New(t.Path).Funcs(functions(t)).Parse(string(bts)); err != nil {
+	// missing values fail rendering, optional values can be read with dig or provided by a default values file
+	// the option applies to every map of the template, not only to values, so a missing key never renders as <no value>
+	if tmpl, err = template.New(t.Path).Funcs(functions(t)).Option("missingkey=error").Parse(string(bts)); err != nil {
 		return nil, err
 	}
 
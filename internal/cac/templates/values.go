@@ -0,0 +1,57 @@
+package templates
+
+import (
+	"os"
+
+	"github.com/cloudentity/cac/internal/cac/secrets"
+	ccyaml "github.com/goccy/go-yaml"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slog"
+)
+
+// ReadValues reads values files and merges them in order, so later files override earlier ones
+// nested maps are merged, while lists and other values are replaced, encrypted values are decrypted with the age configuration
+func ReadValues(age *secrets.AgeConfiguration, files ...string) (map[string]any, error) {
+	var values = map[string]any{}
+
+	for _, file := range files {
+		var (
+			bts []byte
+			v   map[string]any
+			err error
+		)
+
+		if bts, err = os.ReadFile(file); err != nil {
+			return nil, errors.Wrapf(err, "failed to read values file %s", file)
+		}
+
+		if err = ccyaml.Unmarshal(bts, &v); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse values file %s", file)
+		}
+
+		if err = age.DecryptValues(v); err != nil {
+			return nil, errors.Wrapf(err, "failed to decrypt values file %s", file)
+		}
+
+		slog.Debug("read values", "file", file)
+
+		values = mergeValues(values, v)
+	}
+
+	return values, nil
+}
+
+func mergeValues(dst map[string]any, src map[string]any) map[string]any {
+	for k, v := range src {
+		if sv, ok := v.(map[string]any); ok {
+			if dv, isMap := dst[k].(map[string]any); isMap {
+				dst[k] = mergeValues(dv, sv)
+				continue
+			}
+		}
+
+		dst[k] = v
+	}
+
+	return dst
+}
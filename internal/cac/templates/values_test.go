@@ -0,0 +1,91 @@
+package templates_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudentity/cac/internal/cac/templates"
+	ccyaml "github.com/goccy/go-yaml"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValues(t *testing.T) {
+	var (
+		dir      = t.TempDir()
+		base     = filepath.Join(dir, "values.yaml")
+		prod     = filepath.Join(dir, "values-prod.yaml")
+		template = filepath.Join(dir, "client.yaml")
+	)
+
+	require.NoError(t, os.WriteFile(base, []byte(`client:
+  name: app
+  redirect_uris: [http://localhost/callback]
+ttl: 10m
+`), 0644))
+	require.NoError(t, os.WriteFile(prod, []byte(`client:
+  redirect_uris: [https://app.example.com/callback]
+`), 0644))
+
+	values, err := templates.ReadValues(nil, base, prod)
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]any{
+		"client": map[string]any{
+			"name":          "app",
+			"redirect_uris": []any{"https://app.example.com/callback"},
+		},
+		"ttl": "10m",
+	}, values)
+
+	t.Run("render values", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(template, []byte(`client_name: {{ .Values.client.name }}
+redirect_uris: {{ .Values.client.redirect_uris | toJson }}
+description: {{ dig "client" "description" "none" .Values }}`), 0644))
+
+		out, err := templates.New(template, templates.WithValues(values)).Render()
+		require.NoError(t, err)
+		require.Equal(t, `client_name: app
+redirect_uris: ["https://app.example.com/callback"]
+description: none`, string(out))
+	})
+
+	t.Run("typed values", func(t *testing.T) {
+		var rendered map[string]any
+
+		require.NoError(t, os.WriteFile(template, []byte(`trusted: {{ .Values.trusted }}
+ttl: {{ .Values.ttl | toJson }}
+port: {{ .Values.port }}
+code: {{ .Values.code | toJson }}
+metadata: {{ .Values.metadata | toJson }}`), 0644))
+
+		out, err := templates.New(template, templates.WithValues(map[string]any{
+			"trusted":  true,
+			"ttl":      "10m",
+			"port":     8443,
+			"code":     "0123",
+			"metadata": map[string]any{"team": "payments", "tags": []any{"a", "b"}},
+		})).Render()
+		require.NoError(t, err)
+		require.NoError(t, ccyaml.Unmarshal(out, &rendered))
+		require.Equal(t, map[string]any{
+			"trusted":  true,
+			"ttl":      "10m",
+			"port":     uint64(8443),
+			"code":     "0123",
+			"metadata": map[string]any{"team": "payments", "tags": []any{"a", "b"}},
+		}, rendered)
+	})
+
+	t.Run("missing value", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(template, []byte(`client_name: {{ .Values.client.missing }}`), 0644))
+
+		_, err := templates.New(template, templates.WithValues(values)).Render()
+		require.ErrorContains(t, err, `map has no entry for key "missing"`)
+	})
+
+	t.Run("missing values file", func(t *testing.T) {
+		_, err := templates.ReadValues(nil, filepath.Join(dir, "missing.yaml"))
+		require.ErrorContains(t, err, "failed to read values file")
+	})
+}
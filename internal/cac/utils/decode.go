@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-json-experiment/json"
+)
+
+// DecodeError is an error of decoding a patch into a model with a JSON pointer of the value which failed to decode
+type DecodeError struct {
+	Path string
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("%s: %s", e.Path, e.Err)
+}
+
+func (e *DecodeError) Pointer() string {
+	return e.Path
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// decodeErrorPointer returns a JSON pointer of the deepest value which can't be decoded into the type
+// the decoder does not report positions, so values are decoded one by one to find the failing one
+func decodeErrorPointer(value any, typ reflect.Type, pointer string) string {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		var (
+			m, ok  = value.(map[string]any)
+			fields = jsonFields(typ)
+		)
+
+		if !ok {
+			return pointer
+		}
+
+		for _, k := range sortedMapKeys(m) {
+			var (
+				p          = pointer + "/" + escapePointer(k)
+				field, has = fields[k]
+			)
+
+			if !has {
+				return p
+			}
+
+			if !decodes(m[k], field) {
+				return decodeErrorPointer(m[k], field, p)
+			}
+		}
+	case reflect.Map:
+		m, ok := value.(map[string]any)
+
+		if !ok {
+			return pointer
+		}
+
+		for _, k := range sortedMapKeys(m) {
+			if !decodes(m[k], typ.Elem()) {
+				return decodeErrorPointer(m[k], typ.Elem(), pointer+"/"+escapePointer(k))
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		s, ok := value.([]any)
+
+		if !ok {
+			return pointer
+		}
+
+		for i, v := range s {
+			if !decodes(v, typ.Elem()) {
+				return decodeErrorPointer(v, typ.Elem(), pointer+"/"+strconv.Itoa(i))
+			}
+		}
+	}
+
+	return pointer
+}
+
+func decodes(value any, typ reflect.Type) bool {
+	bts, err := json.Marshal(value)
+
+	if err != nil {
+		return false
+	}
+
+	return json.Unmarshal(bts, reflect.New(typ).Interface(), json.RejectUnknownMembers(true)) == nil
+}
+
+// jsonFields returns types of struct fields by their JSON names, including fields of embedded structs
+func jsonFields(typ reflect.Type) map[string]reflect.Type {
+	var out = map[string]reflect.Type{}
+
+	for i := 0; i < typ.NumField(); i++ {
+		var (
+			field      = typ.Field(i)
+			tag        = field.Tag.Get("json")
+			name, _, _ = strings.Cut(tag, ",")
+		)
+
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				for k, v := range jsonFields(ft) {
+					out[k] = v
+				}
+
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		out[name] = field.Type
+	}
+
+	return out
+}
+
+func sortedMapKeys(m map[string]any) []string {
+	var out = make([]string, 0, len(m))
+
+	for k := range m {
+		out = append(out, k)
+	}
+
+	sort.Strings(out)
+
+	return out
+}
+
+var (
+	pointerEscaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+func escapePointer(segment string) string {
+	return pointerEscaper.Replace(segment)
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"reflect"
+
 	"github.com/cloudentity/acp-client-go/clients/hub/models"
 	"github.com/go-json-experiment/json"
 	"github.com/pkg/errors"
@@ -38,6 +40,12 @@ func FromPatchToModel[T any](patch models.Rfc7396PatchOperation) (*T, error) {
 	}
 
 	if err = json.Unmarshal(bts, out, json.RejectUnknownMembers(true)); err != nil {
+		var value any
+
+		if json.Unmarshal(bts, &value) == nil {
+			err = &DecodeError{Path: decodeErrorPointer(value, reflect.TypeOf(out), ""), Err: err}
+		}
+
 		return out, errors.Wrapf(err, "failed to unmarshal json to %T", out)
 	}
 
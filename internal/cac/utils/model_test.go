@@ -81,3 +81,17 @@ func TestFilterPatch(t *testing.T) {
 		})
 	}
 }
+
+func TestFromPatchToModelDecodeError(t *testing.T) {
+	_, err := utils.FromPatchToModel[models.TreeServer](models.Rfc7396PatchOperation{
+		"name": "demo",
+		"clients": map[string]any{
+			"c1": map[string]any{"client_name": "client1"},
+			"c2": map[string]any{"client_name": "client2", "scopes": "openid"},
+		},
+	})
+
+	var decodeErr *utils.DecodeError
+	require.ErrorAs(t, err, &decodeErr)
+	require.Equal(t, "/clients/c2/scopes", decodeErr.Pointer())
+}
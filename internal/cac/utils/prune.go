@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+)
+
+// PrunableCollections are workspace collections which entities are stored in separate files
+var PrunableCollections = []string{
+	"clients",
+	"idps",
+	"policies",
+	"scripts",
+	"services",
+	"webhooks",
+	"gateways",
+	"pools",
+	"custom_apps",
+}
+
+// TenantPrunableCollections are tenant collections which entities are stored in separate files
+var TenantPrunableCollections = []string{
+	"pools",
+}
+
+type Deletion struct {
+	Workspace  string
+	Collection string
+	ID         string
+	Name       string
+}
+
+// PruneServer returns entities present at remote and missing at local workspace configuration
+// when filters are provided, only filtered collections are taken into account
+func PruneServer(local models.Rfc7396PatchOperation, remote models.Rfc7396PatchOperation, workspace string, filters []string) []Deletion {
+	return prune(local, remote, workspace, filterCollections(PrunableCollections, filters))
+}
+
+// PruneTenant returns entities present at remote and missing at local tenant configuration including its workspaces
+func PruneTenant(local models.Rfc7396PatchOperation, remote models.Rfc7396PatchOperation, filters []string) []Deletion {
+	var (
+		deletions     = prune(local, remote, "", filterCollections(TenantPrunableCollections, filters))
+		localServers  = AsMap(local["servers"])
+		remoteServers = AsMap(remote["servers"])
+		ids           = make([]string, 0, len(localServers))
+	)
+
+	if len(filters) > 0 && !slices.Contains(filters, "servers") {
+		return deletions
+	}
+
+	for id := range localServers {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		deletions = append(deletions, prune(AsMap(localServers[id]), AsMap(remoteServers[id]), id, PrunableCollections)...)
+	}
+
+	return deletions
+}
+
+// ApplyDeletions adds RFC 7396 null entries for deleted entities to the patch
+// a tenant patch holds workspace entities under servers, while a workspace patch holds them at the top level
+func ApplyDeletions(patch models.Rfc7396PatchOperation, deletions []Deletion, tenant bool) {
+	for _, d := range deletions {
+		var target map[string]any = patch
+
+		if tenant && d.Workspace != "" {
+			target = childMap(childMap(patch, "servers"), d.Workspace)
+		}
+
+		childMap(target, d.Collection)[d.ID] = nil
+	}
+}
+
+func prune(local map[string]any, remote map[string]any, workspace string, collections []string) []Deletion {
+	var deletions []Deletion
+
+	for _, collection := range collections {
+		var (
+			localEntities  = AsMap(local[collection])
+			remoteEntities = AsMap(remote[collection])
+			ids            = make([]string, 0, len(remoteEntities))
+		)
+
+		for id := range remoteEntities {
+			if _, ok := localEntities[id]; !ok {
+				ids = append(ids, id)
+			}
+		}
+
+		sort.Strings(ids)
+
+		for _, id := range ids {
+			deletions = append(deletions, Deletion{
+				Workspace:  workspace,
+				Collection: collection,
+				ID:         id,
+				Name:       EntityName(AsMap(remoteEntities[id])),
+			})
+		}
+	}
+
+	return deletions
+}
+
+func filterCollections(collections []string, filters []string) []string {
+	if len(filters) == 0 {
+		return collections
+	}
+
+	var out []string
+
+	for _, c := range collections {
+		if slices.Contains(filters, c) {
+			out = append(out, c)
+		}
+	}
+
+	return out
+}
+
+// EntityName returns human-readable name of the entity
+func EntityName(entity map[string]any) string {
+	for _, key := range []string{"client_name", "policy_name", "name", "mechanism"} {
+		if name, ok := entity[key].(string); ok && name != "" {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// AsMap returns the value as map or an empty map if the value is not a map
+func AsMap(v any) map[string]any {
+	switch m := v.(type) {
+	case map[string]any:
+		return m
+	case models.Rfc7396PatchOperation:
+		return m
+	}
+
+	return map[string]any{}
+}
+
+func childMap(parent map[string]any, key string) map[string]any {
+	if child, ok := parent[key].(map[string]any); ok {
+		return child
+	}
+
+	child := map[string]any{}
+	parent[key] = child
+
+	return child
+}
+
+// RemoveNulls removes null values, which in RFC 7396 patch mean deletions, from the patch
+// and returns sorted JSON pointers of the removed values
+func RemoveNulls(patch map[string]any) []string {
+	var removed []string
+
+	removeNulls(patch, "", &removed)
+	sort.Strings(removed)
+
+	return removed
+}
+
+func removeNulls(patch map[string]any, pointer string, removed *[]string) {
+	for k, v := range patch {
+		var p = pointer + "/" + escapePointer(k)
+
+		if v == nil {
+			delete(patch, k)
+			*removed = append(*removed, p)
+			continue
+		}
+
+		if m, ok := v.(map[string]any); ok {
+			removeNulls(m, p, removed)
+		}
+	}
+}
+
+// Present returns true if the patch holds a non null value under the JSON pointer
+func Present(patch map[string]any, pointer string) bool {
+	var current any = patch
+
+	for _, segment := range strings.Split(pointer, "/")[1:] {
+		m, ok := current.(map[string]any)
+
+		if !ok {
+			return false
+		}
+
+		if current, ok = m[pointerUnescaper.Replace(segment)]; !ok || current == nil {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,117 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/cloudentity/acp-client-go/clients/hub/models"
+	"github.com/cloudentity/cac/internal/cac/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrune(t *testing.T) {
+	var (
+		local = models.Rfc7396PatchOperation{
+			"name": "demo",
+			"clients": map[string]any{
+				"c1": map[string]any{"client_name": "client1"},
+			},
+		}
+		remote = models.Rfc7396PatchOperation{
+			"name": "demo",
+			"clients": map[string]any{
+				"c1": map[string]any{"client_name": "client1"},
+				"c2": map[string]any{"client_name": "client2"},
+			},
+			"policies": map[string]any{
+				"p1": map[string]any{"policy_name": "policy1"},
+			},
+			"scopes_without_service": map[string]any{
+				"s1": map[string]any{"name": "scope1"},
+			},
+		}
+	)
+
+	t.Run("prune all collections", func(t *testing.T) {
+		deletions := utils.PruneServer(local, remote, "demo", nil)
+
+		require.Equal(t, []utils.Deletion{
+			{Workspace: "demo", Collection: "clients", ID: "c2", Name: "client2"},
+			{Workspace: "demo", Collection: "policies", ID: "p1", Name: "policy1"},
+		}, deletions)
+	})
+
+	t.Run("apply deletions to a workspace patch", func(t *testing.T) {
+		patch := models.Rfc7396PatchOperation{"name": "demo"}
+		utils.ApplyDeletions(patch, utils.PruneServer(local, remote, "demo", nil), false)
+
+		require.Equal(t, models.Rfc7396PatchOperation{
+			"name":     "demo",
+			"clients":  map[string]any{"c2": nil},
+			"policies": map[string]any{"p1": nil},
+		}, patch)
+	})
+
+	t.Run("prune only filtered collections", func(t *testing.T) {
+		deletions := utils.PruneServer(local, remote, "demo", []string{"clients"})
+
+		require.Equal(t, []utils.Deletion{
+			{Workspace: "demo", Collection: "clients", ID: "c2", Name: "client2"},
+		}, deletions)
+	})
+
+	t.Run("prune tenant workspaces", func(t *testing.T) {
+		deletions := utils.PruneTenant(
+			models.Rfc7396PatchOperation{"servers": map[string]any{"demo": map[string]any(local)}},
+			models.Rfc7396PatchOperation{"servers": map[string]any{"demo": map[string]any(remote), "other": map[string]any{}}},
+			nil,
+		)
+
+		require.Len(t, deletions, 2)
+
+		patch := models.Rfc7396PatchOperation{}
+		utils.ApplyDeletions(patch, deletions, true)
+
+		require.Equal(t, models.Rfc7396PatchOperation{
+			"servers": map[string]any{
+				"demo": map[string]any{
+					"clients":  map[string]any{"c2": nil},
+					"policies": map[string]any{"p1": nil},
+				},
+			},
+		}, patch)
+	})
+}
+
+func TestRemoveNulls(t *testing.T) {
+	patch := models.Rfc7396PatchOperation{
+		"name": "demo",
+		"clients": map[string]any{
+			"c1": map[string]any{"client_name": "client1", "description": nil},
+			"c2": nil,
+		},
+	}
+
+	removed := utils.RemoveNulls(patch)
+
+	require.Equal(t, models.Rfc7396PatchOperation{
+		"name": "demo",
+		"clients": map[string]any{
+			"c1": map[string]any{"client_name": "client1"},
+		},
+	}, patch)
+	require.Equal(t, []string{"/clients/c1/description", "/clients/c2"}, removed)
+}
+
+func TestPresent(t *testing.T) {
+	patch := models.Rfc7396PatchOperation{
+		"clients": map[string]any{
+			"c/1": map[string]any{"client_name": "client1", "description": nil},
+		},
+	}
+
+	require.True(t, utils.Present(patch, "/clients/c~11"))
+	require.True(t, utils.Present(patch, "/clients/c~11/client_name"))
+	require.False(t, utils.Present(patch, "/clients/c~11/description"))
+	require.False(t, utils.Present(patch, "/clients/c2"))
+	require.False(t, utils.Present(patch, "/clients/c~11/client_name/x"))
+}